@@ -0,0 +1,470 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// appType is a type of main package given with -type, or of library package
+// given with -lib, and the files and commands that create it.
+type appType struct {
+	// file contains the files of the main package and any other packages it
+	// uses, or their definitions, whose paths are rendered with the template
+	// variables.
+	file []fileSpec
+	// require contains the modules required by its files.
+	require []string
+	// target contains the targets of the Makefile generating any other files.
+	target []makeTarget
+	// env contains the export statements of .envrc, with -envrc, defining the
+	// environment variables read by its main package.
+	env []string
+	// generate is true if the go generate directives of its files are run
+	// before go.mod is tidied.
+	generate bool
+	// broker contains the variants of a type for each message broker given
+	// with -broker, keyed by name, which are its only files.
+	broker map[string]appType
+	// gateway is its variant given with -gateway, if any.
+	gateway *appType
+	// server is true if it serves network clients, and its main package also
+	// serves the debug endpoints of debugTemplate with -debug-endpoints.
+	server bool
+	// metrics is the file metrics.go of its main package with -metrics, if
+	// any, serving its Prometheus metrics.
+	metrics Template
+	// lib is true if it is instead a type of library package, given with -lib,
+	// whose files replace those of the built-in library package.
+	lib bool
+	// readme, if any, replaces the base of its README.md.
+	readme Template
+}
+
+// debugTemplate is the file debug.go of the main package of a type of server
+// with -debug-endpoints, serving the debug endpoints on its -debug-addr flag's
+// address.
+var debugTemplate = builtin.must("debug.go")
+
+var (
+	// metricsHTTP is the file metrics.go of the main package of a type of HTTP
+	// server with -metrics, also serving its metrics at /metrics.
+	metricsHTTP = builtin.must("metrics/http.go")
+	// metricsGRPC is the file metrics.go of the main package of a type of gRPC
+	// server with -metrics, serving its metrics on its -metrics-addr flag's
+	// address.
+	metricsGRPC = builtin.must("metrics/grpc.go")
+)
+
+// metricsModule is the module required by the file metrics.go of -metrics.
+const metricsModule = "github.com/prometheus/client_golang"
+
+// appTypes contains each type of main package given with -type, keyed by name.
+// The default type, "flag", is the single file of the built-in main package,
+// accepting command-line flags with package flag.
+var appTypes = map[string]appType{
+	"flag": {},
+	"cgo": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("cgo/main.go"), nil},
+			{"bridge.go", "source", 0664, builtin.must("cgo/bridge.go"), nil},
+			{"greet.h", "source", 0664, builtin.must("cgo/greet.h"), nil},
+			{"greet.c", "source", 0664, builtin.must("cgo/greet.c"), nil},
+		},
+		target: []makeTarget{
+			{
+				name:   "build",
+				help:   "build the program, compiling its C sources with cgo",
+				recipe: []string{`CGO_ENABLED=1 go build .`},
+			},
+		},
+	},
+	"client": {
+		file: []fileSpec{
+			{"doc.go", "source", 0664, libDocTemplate, nil},
+			{"client.go", "source", 0664, builtin.must("client/client.go"), nil},
+			{"items.go", "source", 0664, builtin.must("client/items.go"), nil},
+			{"client_test.go", "source", 0664, builtin.must("client/client_test.go"), nil},
+		},
+		lib:    true,
+		readme: builtin.must("client/README.md"),
+	},
+	"cloudrun": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("cloudrun/main.go"), nil},
+			{"server.go", "source", 0664, builtin.must("http/server.go"), nil},
+			{"Dockerfile", "doc", 0664, builtin.must("cloudrun/Dockerfile"), nil},
+			{".dockerignore", "doc", 0664, builtin.must("cloudrun/dockerignore"), nil},
+		},
+		target: []makeTarget{
+			{
+				name:   "image",
+				help:   "build the container image",
+				recipe: []string{`docker build -t __NAME__ .`},
+			},
+			{
+				name:   "run",
+				help:   "run the container image, listening on localhost:8080",
+				recipe: []string{`docker run --rm -p 8080:8080 __NAME__`},
+			},
+			{
+				name:   "deploy",
+				help:   "build and deploy the service to Cloud Run",
+				recipe: []string{`gcloud run deploy __NAME__ --source .`},
+			},
+		},
+		env:     []string{`export PORT="8080"`},
+		server:  true,
+		metrics: metricsHTTP,
+	},
+	"cobra": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("cobra/main.go"), nil},
+			{filepath.Join("cmd", "root.go"), "source", 0664, builtin.must("cobra/root.go"), nil},
+			{filepath.Join("cmd", "version.go"), "source", 0664, builtin.must("cobra/version.go"), nil},
+		},
+		require: []string{"github.com/spf13/cobra"},
+	},
+	"consumer": {
+		broker: map[string]appType{
+			"nats": {
+				file: []fileSpec{
+					{"main.go", "source", 0664, builtin.must("consumer/nats.go"), nil},
+					{"handler.go", "source", 0664, builtin.must("consumer/handler.go"), nil},
+				},
+				require: []string{"github.com/nats-io/nats.go"},
+			},
+			"kafka": {
+				file: []fileSpec{
+					{"main.go", "source", 0664, builtin.must("consumer/kafka.go"), nil},
+					{"handler.go", "source", 0664, builtin.must("consumer/handler.go"), nil},
+				},
+				require: []string{"github.com/segmentio/kafka-go"},
+			},
+		},
+	},
+	"cron": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("cron/main.go"), nil},
+			{"jobs.go", "source", 0664, builtin.must("cron/jobs.go"), nil},
+		},
+		require: []string{"github.com/robfig/cron/v3"},
+	},
+	"daemon": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("daemon/main.go"), nil},
+		},
+	},
+	"database": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("database/main.go"), nil},
+			{"sqlc.yaml", "doc", 0664, builtin.must("database/sqlc.yaml"), nil},
+			{filepath.Join("migrations", "00001_create_items.sql"), "source", 0664, builtin.must("database/migration.sql"), nil},
+			{filepath.Join("migrations", "migrations.go"), "source", 0664, builtin.must("database/migrations.go"), nil},
+			{filepath.Join("store", "query.sql"), "source", 0664, builtin.must("database/query.sql"), nil},
+			{filepath.Join("store", "db", "db.go"), "source", 0664, builtin.must("database/db.go"), nil},
+			{filepath.Join("store", "db", "models.go"), "source", 0664, builtin.must("database/models.go"), nil},
+			{filepath.Join("store", "db", "query.sql.go"), "source", 0664, builtin.must("database/query.sql.go"), nil},
+			{filepath.Join("store", "store.go"), "source", 0664, builtin.must("database/store.go"), nil},
+			{filepath.Join("store", "store_test.go"), "source", 0664, builtin.must("database/store_test.go"), nil},
+		},
+		require: []string{"github.com/jackc/pgx/v5", "github.com/pressly/goose/v3", "github.com/ory/dockertest/v3"},
+		target: []makeTarget{
+			{
+				name:   "generate",
+				help:   "generate the queries of store/query.sql into store/db",
+				recipe: []string{`go run github.com/sqlc-dev/sqlc/cmd/sqlc@latest generate`},
+			},
+			{
+				name:   "migrate",
+				help:   "apply the migrations in migrations/ to the database at $DATABASE_URL",
+				recipe: []string{`go run github.com/pressly/goose/v3/cmd/goose@latest -dir migrations postgres "$$DATABASE_URL" up`},
+			},
+		},
+		env: []string{`export DATABASE_URL="postgres://localhost:5432/__NAME__?sslmode=disable"`},
+	},
+	"graphql": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("graphql/main.go"), nil},
+			{"tools.go", "source", 0664, builtin.must("graphql/tools.go"), nil},
+			{"gqlgen.yml", "doc", 0664, builtin.must("graphql/gqlgen.yml"), nil},
+			{filepath.Join("graph", "schema.graphqls"), "doc", 0664, builtin.must("graphql/schema.graphqls"), nil},
+			{filepath.Join("graph", "resolver.go"), "source", 0664, builtin.must("graphql/resolver.go"), nil},
+			{filepath.Join("graph", "schema.resolvers.go"), "source", 0664, builtin.must("graphql/schema.resolvers.go"), nil},
+		},
+		require: []string{"github.com/99designs/gqlgen"},
+		target: []makeTarget{
+			{
+				name:   "generate",
+				help:   "generate the GraphQL server and resolver stubs of graph/schema.graphqls",
+				recipe: []string{`go generate ./...`},
+			},
+		},
+		generate: true,
+		server:   true,
+		metrics:  metricsHTTP,
+	},
+	"grpc": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("grpc/main.go"), nil},
+			{filepath.Join("proto", "api", "v1", "api.proto"), "source", 0664, builtin.must("grpc/api.proto"), nil},
+			{"buf.yaml", "doc", 0664, builtin.must("grpc/buf.yaml"), nil},
+			{"buf.gen.yaml", "doc", 0664, builtin.must("grpc/buf.gen.yaml"), nil},
+		},
+		require: []string{"google.golang.org/grpc"},
+		target: []makeTarget{
+			{
+				name: "tools",
+				help: "install buf and the protoc plugins generating Go code",
+				recipe: []string{
+					`go install github.com/bufbuild/buf/cmd/buf@latest`,
+					`go install google.golang.org/protobuf/cmd/protoc-gen-go@latest`,
+					`go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest`,
+				},
+			},
+			{
+				name:   "generate",
+				help:   "generate the Go code of the protobuf definitions in proto/ into gen/",
+				recipe: []string{`buf lint`, `buf generate`},
+			},
+		},
+		gateway: &appType{
+			file: []fileSpec{
+				{"main.go", "source", 0664, builtin.must("grpc/gateway.go"), nil},
+				{filepath.Join("proto", "api", "v1", "api.proto"), "source", 0664, builtin.must("grpc/gateway.proto"), nil},
+				{"buf.yaml", "doc", 0664, builtin.must("grpc/gateway.buf.yaml"), nil},
+				{"buf.gen.yaml", "doc", 0664, builtin.must("grpc/gateway.buf.gen.yaml"), nil},
+			},
+			require: []string{"google.golang.org/grpc", "github.com/grpc-ecosystem/grpc-gateway/v2"},
+			target: []makeTarget{
+				{
+					name: "tools",
+					help: "install buf and the protoc plugins generating Go code",
+					recipe: []string{
+						`go install github.com/bufbuild/buf/cmd/buf@latest`,
+						`go install google.golang.org/protobuf/cmd/protoc-gen-go@latest`,
+						`go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest`,
+						`go install github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway@latest`,
+					},
+				},
+				{
+					name:   "generate",
+					help:   "generate the Go code of the protobuf definitions in proto/ into gen/",
+					recipe: []string{`buf dep update`, `buf lint`, `buf generate`},
+				},
+			},
+			server:  true,
+			metrics: metricsGRPC,
+		},
+		server:  true,
+		metrics: metricsGRPC,
+	},
+	"http": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("http/main.go"), nil},
+			{"server.go", "source", 0664, builtin.must("http/server.go"), nil},
+		},
+		server:  true,
+		metrics: metricsHTTP,
+	},
+	"openapi": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("openapi/main.go"), nil},
+			{"tools.go", "source", 0664, builtin.must("openapi/tools.go"), nil},
+			{"openapi.yaml", "doc", 0664, builtin.must("openapi/openapi.yaml"), nil},
+			{filepath.Join("api", "oapi-codegen.yaml"), "doc", 0664, builtin.must("openapi/oapi-codegen.yaml"), nil},
+			{filepath.Join("api", "generate.go"), "source", 0664, builtin.must("openapi/generate.go"), nil},
+			{filepath.Join("api", "server.go"), "source", 0664, builtin.must("openapi/server.go"), nil},
+		},
+		require: []string{"github.com/oapi-codegen/oapi-codegen/v2"},
+		target: []makeTarget{
+			{
+				name:   "generate",
+				help:   "generate the server of the API defined by openapi.yaml",
+				recipe: []string{`go generate ./...`},
+			},
+		},
+		generate: true,
+		server:   true,
+		metrics:  metricsHTTP,
+	},
+	"operator": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("operator/main.go"), nil},
+			{filepath.Join("api", "v1alpha1", "groupversion_info.go"), "source", 0664, builtin.must("operator/groupversion_info.go"), nil},
+			{filepath.Join("api", "v1alpha1", "widget_types.go"), "source", 0664, builtin.must("operator/widget_types.go"), nil},
+			{filepath.Join("api", "v1alpha1", "zz_generated.deepcopy.go"), "source", 0664, builtin.must("operator/zz_generated.deepcopy.go"), nil},
+			{filepath.Join("internal", "controller", "widget_controller.go"), "source", 0664, builtin.must("operator/widget_controller.go"), nil},
+			{filepath.Join("config", "crd", "bases", "__NAME__.example.com_widgets.yaml"), "doc", 0664, builtin.must("operator/crd.yaml"), nil},
+			{filepath.Join("config", "crd", "kustomization.yaml"), "doc", 0664, builtin.must("operator/crd_kustomization.yaml"), nil},
+			{filepath.Join("config", "rbac", "service_account.yaml"), "doc", 0664, builtin.must("operator/service_account.yaml"), nil},
+			{filepath.Join("config", "rbac", "role.yaml"), "doc", 0664, builtin.must("operator/role.yaml"), nil},
+			{filepath.Join("config", "rbac", "role_binding.yaml"), "doc", 0664, builtin.must("operator/role_binding.yaml"), nil},
+			{filepath.Join("config", "rbac", "leader_election_role.yaml"), "doc", 0664, builtin.must("operator/leader_election_role.yaml"), nil},
+			{filepath.Join("config", "rbac", "kustomization.yaml"), "doc", 0664, builtin.must("operator/rbac_kustomization.yaml"), nil},
+			{filepath.Join("config", "manager", "manager.yaml"), "doc", 0664, builtin.must("operator/manager.yaml"), nil},
+			{filepath.Join("config", "manager", "kustomization.yaml"), "doc", 0664, builtin.must("operator/manager_kustomization.yaml"), nil},
+			{filepath.Join("config", "default", "kustomization.yaml"), "doc", 0664, builtin.must("operator/default_kustomization.yaml"), nil},
+			{"Dockerfile", "doc", 0664, builtin.must("operator/Dockerfile"), nil},
+		},
+		require: []string{"sigs.k8s.io/controller-runtime"},
+		target: []makeTarget{
+			{
+				name:   "generate",
+				help:   "generate the DeepCopy methods of the API types in api/",
+				recipe: []string{`go run sigs.k8s.io/controller-tools/cmd/controller-gen@latest object paths=./...`},
+			},
+			{
+				name: "manifests",
+				help: "generate the CustomResourceDefinitions and RBAC roles in config/",
+				recipe: []string{
+					`go run sigs.k8s.io/controller-tools/cmd/controller-gen@latest crd rbac:roleName=manager-role paths=./... output:crd:artifacts:config=config/crd/bases`,
+				},
+			},
+			{
+				name:   "image",
+				help:   "build the container image of the manager",
+				recipe: []string{`docker build -t __NAME__:latest .`},
+			},
+			{
+				name:   "install",
+				help:   "install the CustomResourceDefinitions into the cluster of the current kubectl context",
+				recipe: []string{`kubectl apply -k config/crd`},
+			},
+			{
+				name:   "uninstall",
+				help:   "uninstall the CustomResourceDefinitions from the cluster",
+				recipe: []string{`kubectl delete -k config/crd`},
+			},
+			{
+				name:   "deploy",
+				help:   "deploy the manager, with its CustomResourceDefinitions and RBAC roles, to the cluster",
+				recipe: []string{`kubectl apply -k config/default`},
+			},
+			{
+				name:   "undeploy",
+				help:   "remove the manager from the cluster",
+				recipe: []string{`kubectl delete -k config/default`},
+			},
+		},
+	},
+	"pipeline": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("pipeline/main.go"), nil},
+			{"pipeline.go", "source", 0664, builtin.must("pipeline/pipeline.go"), nil},
+		},
+		require: []string{"golang.org/x/sync"},
+	},
+	"plugin": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("plugin/main.go"), nil},
+			{filepath.Join("ext", "ext.go"), "source", 0664, builtin.must("plugin/ext.go"), nil},
+			{filepath.Join("ext", "hello", "main.go"), "source", 0664, builtin.must("plugin/hello.go"), nil},
+		},
+		target: []makeTarget{
+			{
+				name:   "plugins",
+				help:   "build the example plugin into plugins/",
+				recipe: []string{`go build -buildmode=plugin -o plugins/hello.so ./ext/hello`},
+			},
+		},
+	},
+	"go-plugin": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("go-plugin/main.go"), nil},
+			{filepath.Join("ext", "ext.go"), "source", 0664, builtin.must("go-plugin/ext.go"), nil},
+			{filepath.Join("ext", "hello", "main.go"), "source", 0664, builtin.must("go-plugin/hello.go"), nil},
+		},
+		require: []string{"github.com/hashicorp/go-plugin"},
+		target: []makeTarget{
+			{
+				name:   "plugins",
+				help:   "build the example plugin into plugins/",
+				recipe: []string{`go build -o plugins/hello ./ext/hello`},
+			},
+		},
+	},
+	"subcommands": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("subcommands/main.go"), nil},
+		},
+	},
+	"tui": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("tui/main.go"), nil},
+		},
+		require: []string{"github.com/charmbracelet/bubbletea"},
+	},
+	"urfave": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("urfave/main.go"), nil},
+		},
+		require: []string{"github.com/urfave/cli/v2"},
+	},
+	"wasm": {
+		file: []fileSpec{
+			{"main.go", "source", 0664, builtin.must("wasm/main.go"), nil},
+			{"index.html", "doc", 0664, builtin.must("wasm/index.html"), nil},
+		},
+		target: []makeTarget{
+			{
+				name: "wasm",
+				help: "build main.wasm and copy the wasm_exec.js loading it into index.html",
+				recipe: []string{
+					`GOOS=js GOARCH=wasm go build -o main.wasm .`,
+					`cp "$$(go env GOROOT)/lib/wasm/wasm_exec.js" . 2>/dev/null || cp "$$(go env GOROOT)/misc/wasm/wasm_exec.js" .`,
+				},
+			},
+		},
+	},
+}
+
+// appTypeDescription contains a short description of each type in appTypes,
+// listed by the templates command.
+var appTypeDescription = map[string]string{
+	"cgo":         "main package calling C functions through a cgo bridge",
+	"client":      "library package of a typed client of an HTTP API, with retries",
+	"cloudrun":    "web service for Cloud Run, listening on $PORT, with a Dockerfile",
+	"cobra":       "cobra root command with a version subcommand",
+	"consumer":    "message-queue consumer of the broker given with -broker",
+	"cron":        "scheduler running jobs on their cron schedules",
+	"daemon":      "long-running service reloading its configuration on SIGHUP",
+	"database":    "service storing items in PostgreSQL, with migrations and sqlc",
+	"flag":        "main package accepting command-line flags with package flag",
+	"go-plugin":   "host of plugins, each an executable called over RPC with go-plugin",
+	"graphql":     "GraphQL server generated by gqlgen from its schema",
+	"grpc":        "gRPC server generated by buf, optionally with a REST gateway",
+	"http":        "web service with middleware and graceful shutdown",
+	"openapi":     "HTTP API generated by oapi-codegen from its OpenAPI contract",
+	"operator":    "Kubernetes operator reconciling a sample custom resource",
+	"pipeline":    "batch-processing tool with a pool of concurrent workers",
+	"plugin":      "host of plugins loaded with the standard plugin package",
+	"subcommands": "main package dispatching subcommands without any framework",
+	"tui":         "Bubble Tea terminal user interface",
+	"urfave":      "urfave/cli application with version and changelog commands",
+	"wasm":        "WebAssembly program exporting a function to JavaScript",
+}
+
+// appTypeNames returns the sorted names of all types of main packages.
+func appTypeNames() []string {
+	name := []string{}
+	for n := range appTypes {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+	return name
+}
+
+// brokerNames returns the sorted names of all message brokers of the types of
+// main packages.
+func brokerNames() []string {
+	seen, name := map[string]bool{}, []string{}
+	for _, t := range appTypes {
+		for n := range t.broker {
+			if !seen[n] {
+				seen[n], name = true, append(name, n)
+			}
+		}
+	}
+	sort.Strings(name)
+	return name
+}
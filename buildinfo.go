@@ -0,0 +1,58 @@
+package main
+
+import (
+	"runtime/debug"
+	"strings"
+
+	"github.com/ardnew/version"
+)
+
+// moduleVersion returns the semantic version of mkgo. Binaries built with
+// "go install" report the version of the module that was installed. Otherwise,
+// including pseudo-versions of untagged source trees, the most recent version
+// in the changelog is used.
+func moduleVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		v := strings.TrimSuffix(strings.TrimPrefix(info.Main.Version, "v"), "+dirty")
+		if v != "" && v != "(devel)" && !strings.HasPrefix(v, "0.0.0-") {
+			return v
+		}
+	}
+	return version.String()
+}
+
+// buildVersion returns the semantic version of mkgo along with the VCS revision
+// and modification state of the source tree it was built from, whenever the Go
+// toolchain recorded them.
+func buildVersion() string {
+	ver := moduleVersion()
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ver
+	}
+	var rev, dirty string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+			if len(rev) > 12 {
+				rev = rev[:12]
+			}
+		case "vcs.modified":
+			if s.Value == "true" {
+				dirty = "dirty"
+			}
+		}
+	}
+	detail := []string{}
+	if rev != "" {
+		detail = append(detail, "revision "+rev)
+	}
+	if dirty != "" {
+		detail = append(detail, dirty)
+	}
+	if len(detail) > 0 {
+		ver += " (" + strings.Join(detail, ", ") + ")"
+	}
+	return ver
+}
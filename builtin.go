@@ -0,0 +1,93 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/ardnew/mkgo/scaffold"
+)
+
+// builtinFS contains the source of every built-in Template.
+//
+//go:embed templates
+var builtinFS embed.FS
+
+// builtin loads the built-in Templates embedded in mkgo.
+var builtin = templateLoader{builtinFS, "templates"}
+
+// licenseTemplate contains the built-in LICENSE Template of each license,
+// keyed by name.
+var licenseTemplate = builtin.all("license")
+
+// licenseNotice contains the notice of each license in licenseTemplate, if it
+// recommends one, that begins every source file when -spdx is given.
+var licenseNotice = builtin.all("license/notice")
+
+// licensePath contains the path of the file, if not LICENSE, of each license in
+// licenseTemplate, following its convention. The GNU Lesser General Public
+// License supplements the GNU General Public License, which is written to
+// COPYING along with it.
+var licensePath = map[string]string{
+	"GPL-3.0-or-later":  "COPYING",
+	"LGPL-3.0-or-later": "COPYING.LESSER",
+	"AGPL-3.0-or-later": "COPYING",
+}
+
+// licenseDescription contains a short description of each license in
+// licenseTemplate, listed by the license chooser of -l ?.
+var licenseDescription = map[string]string{
+	"AGPL-3.0-or-later": "GNU Affero General Public License v3.0 or later: copyleft, including network use",
+	"Apache-2.0":        "Apache License 2.0: permissive, with a patent grant",
+	"BSD-2-Clause":      "BSD 2-Clause \"Simplified\" License: permissive",
+	"BSD-3-Clause":      "BSD 3-Clause \"New\" License: permissive, without endorsement",
+	"GPL-3.0-or-later":  "GNU General Public License v3.0 or later: copyleft",
+	"ISC":               "ISC License: permissive, equivalent to MIT",
+	"LGPL-3.0-or-later": "GNU Lesser General Public License v3.0 or later: copyleft, except linking",
+	"MIT":               "MIT License: permissive",
+	"MPL-2.0":           "Mozilla Public License 2.0: copyleft of each file",
+	"Unlicense":         "The Unlicense: public domain dedication",
+}
+
+// templateLoader loads Templates from the files with extension
+// scaffold.TemplateExt in the directory dir of an fs.FS. The final newline of
+// each file is not part of its Template.
+type templateLoader struct {
+	fsys fs.FS
+	dir  string
+}
+
+// load returns the Template in the file of the receiver templateLoader l with
+// the given slash-separated name, without its extension.
+func (l templateLoader) load(name string) (Template, error) {
+	b, err := fs.ReadFile(l.fsys, path.Join(l.dir, name+scaffold.TemplateExt))
+	if nil != err {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n"), nil
+}
+
+// must returns the Template in the file of the receiver templateLoader l with
+// the given name, as with load, and panics if it cannot be read. It is used
+// only to load built-in Templates, which always exist.
+func (l templateLoader) must(name string) Template {
+	tmpl, err := l.load(name)
+	if nil != err {
+		panic(err)
+	}
+	return tmpl
+}
+
+// all returns every Template in the given slash-separated subdirectory sub of
+// the receiver templateLoader l, keyed by name.
+func (l templateLoader) all(sub string) map[string]Template {
+	all := map[string]Template{}
+	ent, _ := fs.ReadDir(l.fsys, path.Join(l.dir, sub))
+	for _, e := range ent {
+		if name, ok := strings.CutSuffix(e.Name(), scaffold.TemplateExt); ok && !e.IsDir() {
+			all[name] = l.must(path.Join(sub, name))
+		}
+	}
+	return all
+}
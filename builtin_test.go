@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/ardnew/mkgo/scaffold"
+)
+
+func TestBuiltinFile(t *testing.T) {
+	err := fs.WalkDir(builtinFS, builtin.dir, func(p string, d fs.DirEntry, err error) error {
+		if nil != err || d.IsDir() || path.Ext(p) != scaffold.TemplateExt {
+			return err
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(p, builtin.dir+"/"), scaffold.TemplateExt)
+		body := builtin.must(name)
+		if err := body.insert(exampleVars(), nil); nil != err {
+			t.Errorf("%s: insert() = %v", name, err)
+			return nil
+		}
+		got := body.file()
+		if !strings.HasSuffix(got, "\n") || strings.HasSuffix(got, "\n\n") {
+			t.Errorf("%s: file() ends in %q, want exactly one newline", name, got[max(0, len(got)-8):])
+		}
+		return nil
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestBuiltinAll(t *testing.T) {
+	for _, sub := range []string{"license", "license/notice"} {
+		all := builtin.all(sub)
+		if len(all) == 0 {
+			t.Errorf("all(%q) is empty", sub)
+		}
+		for name, tmpl := range all {
+			body := append(Template{}, tmpl...)
+			if err := body.insert(exampleVars(), nil); nil != err {
+				t.Errorf("%s/%s: insert() = %v", sub, name, err)
+				continue
+			}
+			if got := body.file(); strings.HasSuffix(got, "\n\n") || !strings.HasSuffix(got, "\n") {
+				t.Errorf("%s/%s: file() does not end in exactly one newline", sub, name)
+			}
+		}
+	}
+}
+
+func TestTemplateFile(t *testing.T) {
+	tests := []struct {
+		tmpl Template
+		want string
+	}{
+		{Template{}, ""},
+		{Template{"a"}, "a\n"},
+		{Template{"a", "b"}, "a\nb\n"},
+		{Template{"a", ""}, "a\n"},
+	}
+	for _, tt := range tests {
+		if got := tt.tmpl.file(); got != tt.want {
+			t.Errorf("%q.file() = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ardnew/mkgo/exitcode"
+	"github.com/ardnew/mkgo/scaffold"
+)
+
+func init() {
+	registerCommand(&command{
+		name:  "capture",
+		args:  "[-name name] [-author name] [-o dir] [-f] <project>",
+		usage: "create a template set from an existing project",
+		run:   runCapture,
+	})
+}
+
+// captureSkip contains the names of the files and directories of a project
+// that are never captured.
+var captureSkip = map[string]bool{
+	".git":   true,
+	"vendor": true,
+	"go.mod": true,
+	"go.sum": true,
+}
+
+var (
+	// captureDate matches the dates, in the default and ISO 8601 formats, that
+	// are replaced by the DATE token.
+	captureDate = regexp.MustCompile(`\b\d{4} (?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) \d{1,2}\b|\b\d{4}-\d{2}-\d{2}\b`)
+	// captureYear matches the years of copyright notices, which are replaced by
+	// the YEAR token.
+	captureYear = regexp.MustCompile(`(?i)(copyright\s+(?:\(c\)\s+|©\s+)?)(\d{4})\b`)
+)
+
+// captureRule replaces the matches of a regular expression with a placeholder
+// token.
+type captureRule struct {
+	re  *regexp.Regexp
+	tok string
+}
+
+// runCapture writes a template set to the user template directory, or the
+// given directory, from the files of the project in the directory given as
+// argument, with its module path, binary name, author, and dates replaced by
+// placeholder tokens.
+func runCapture(arg []string) exitcode.Code {
+	var argName, argAuthor, argOut string
+	var argForce bool
+	fs := flag.NewFlagSet("capture", flag.ContinueOnError)
+	fs.StringVar(&argName, "name", "", "name of the template set (default: the project's name)")
+	fs.StringVar(&argAuthor, "author", os.Getenv("USER"), "name of the project's author")
+	fs.StringVar(&argOut, "o", "", "output directory (default: $XDG_CONFIG_HOME/mkgo/templates/name)")
+	fs.BoolVar(&argForce, "f", false, "force overwriting an existing template set")
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if fs.NArg() != 1 {
+		logger.Error("expected one project directory (use -h for help)")
+		return exitcode.Usage
+	}
+	src := fs.Arg(0)
+	imp := scaffold.ModulePath(os.DirFS(src))
+	if imp == "" {
+		logger.Error("project has no go.mod with a module path", "path", src)
+		return exitcode.Template
+	}
+	repo, _ := splitMajor(imp)
+	_, name := packagePath(repo)
+	if argName == "" {
+		argName = name
+	}
+	if argOut == "" {
+		cfg := configPath()
+		if cfg == "" {
+			logger.Error("cannot determine user template directory (use -o)")
+			return exitcode.Usage
+		}
+		argOut = filepath.Join(filepath.Dir(cfg), "templates", argName)
+	}
+	if exists, _ := fileExists(argOut); exists && !argForce {
+		logger.Error("template set exists (use -f to overwrite)", "path", argOut)
+		return exitcode.Template
+	}
+
+	// longer strings are replaced first, so that the module path is replaced
+	// before the name it contains.
+	repl := []captureRule{
+		{regexp.MustCompile(regexp.QuoteMeta(imp) + `\b`), "__IMPORT__"},
+		{regexp.MustCompile(regexp.QuoteMeta(repo) + `\b`), "__REPO__"},
+		{captureDate, "__DATE__"},
+		{captureYear, "${1}__YEAR__"},
+	}
+	if argAuthor != "" {
+		repl = append(repl,
+			captureRule{regexp.MustCompile(`\b` + regexp.QuoteMeta(argAuthor) + `\b`), "__USER__"},
+			// the author of a copyright notice is its holder.
+			captureRule{regexp.MustCompile(`(__YEAR__\s+)__USER__`), "${1}__HOLDER__"})
+	}
+	repl = append(repl, captureRule{regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`), "__NAME__"})
+	capture := func(s string) string {
+		// tokens already in the project are rendered as is.
+		s = scaffold.QuoteTokens(s)
+		for _, r := range repl {
+			s = r.re.ReplaceAllString(s, r.tok)
+		}
+		return s
+	}
+
+	count := 0
+	err := filepath.WalkDir(src, func(p string, d os.DirEntry, err error) error {
+		if nil != err {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if nil != err || rel == "." {
+			return err
+		}
+		if captureSkip[d.Name()] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if nil != err || !info.Mode().IsRegular() {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if nil != err {
+			return err
+		}
+		out := filepath.Join(argOut, filepath.FromSlash(capture(filepath.ToSlash(rel))))
+		if !scaffold.IsBinary(b) {
+			b = []byte(capture(string(b)))
+		}
+		if err := os.MkdirAll(filepath.Dir(out), 0775); nil != err {
+			return err
+		}
+		count++
+		return os.WriteFile(out, b, info.Mode().Perm())
+	})
+	if nil != err {
+		logger.Error("cannot capture project", "path", src, "error", err)
+		return exitcode.Template
+	}
+	if err := writeCaptureManifest(filepath.Join(argOut, scaffold.ManifestName), argName, imp,
+		filepath.Join(src, "go.mod")); nil != err {
+		logger.Error("cannot write manifest", "path", argOut, "error", err)
+		return exitcode.Template
+	}
+	logger.Info("captured template set", "name", argName, "path", argOut, "files", count)
+	return exitcode.OK
+}
+
+// writeCaptureManifest writes the manifest of the template set with the given
+// name, captured from the module with the given import path imp, to the file
+// at the given path. The modules directly required by the module's go.mod file
+// at the given path gomod are required by the template set.
+func writeCaptureManifest(path, name, imp, gomod string) error {
+	req, err := directRequires(gomod)
+	if nil != err {
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "name: %s\n", name)
+	fmt.Fprintf(&b, "description: %q\n", "captured from "+imp)
+	if len(req) > 0 {
+		fmt.Fprintf(&b, "requires:\n")
+		for _, r := range req {
+			fmt.Fprintf(&b, "  - %s\n", r)
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0664)
+}
+
+// directRequires returns the modules, each with its version suffix, directly
+// required by the go.mod file at the given path, i.e., those not marked
+// "// indirect".
+func directRequires(gomod string) ([]string, error) {
+	f, err := os.Open(gomod)
+	if nil != err {
+		return nil, err
+	}
+	defer f.Close()
+	req := []string{}
+	block := false
+	for scan := bufio.NewScanner(f); scan.Scan(); {
+		line := strings.TrimSpace(scan.Text())
+		switch {
+		case line == "require (":
+			block = true
+			continue
+		case block && line == ")":
+			block = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !block:
+			continue
+		}
+		if strings.Contains(line, "// indirect") {
+			continue
+		}
+		if field := strings.Fields(line); len(field) >= 2 {
+			req = append(req, field[0]+"@"+field[1])
+		}
+	}
+	return req, nil
+}
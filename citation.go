@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ardnew/version"
+)
+
+// citation returns the CITATION.cff file, in Citation File Format 1.2.0, that
+// cites the generated module's first revision, released on the given date by
+// the given authors under the given licenses and described by the given
+// keywords.
+func citation(authors []string, date string, license, keywords []string) Template {
+	if t := version.ParseDate(date); t != nil {
+		date = t.Format("2006-01-02") // the format required by CFF
+	}
+	tmpl := Template{
+		`cff-version: 1.2.0`,
+		`message: "If you use this software, please cite it as below."`,
+		`title: "__NAME__"`,
+		`version: "__VERSION__"`,
+		`date-released: ` + strconv.Quote(date),
+	}
+	switch len(license) {
+	case 0:
+	case 1:
+		tmpl = append(tmpl, `license: `+license[0])
+	default:
+		tmpl = append(tmpl, `license:`)
+		for _, l := range license {
+			tmpl = append(tmpl, `  - `+l)
+		}
+	}
+	tmpl = append(tmpl,
+		`repository-code: "https://__IMPORT__"`,
+		`authors:`)
+	for _, a := range authors {
+		name, email := splitAuthor(a)
+		given, family := name, ""
+		if i := strings.LastIndexByte(name, ' '); i > 0 {
+			given, family = name[:i], name[i+1:]
+		}
+		if family != "" {
+			tmpl = append(tmpl, `  - family-names: `+strconv.Quote(family),
+				`    given-names: `+strconv.Quote(given))
+		} else {
+			tmpl = append(tmpl, `  - given-names: `+strconv.Quote(given))
+		}
+		if email != "" {
+			tmpl = append(tmpl, `    email: `+strconv.Quote(email))
+		}
+	}
+	if len(keywords) > 0 {
+		tmpl = append(tmpl, `keywords:`)
+		for _, k := range keywords {
+			tmpl = append(tmpl, `  - `+strconv.Quote(k))
+		}
+	}
+	return append(tmpl, ``)
+}
+
+// splitAuthor returns the name and email address of the given author a, which
+// is formatted as "Name <email address>" or only "Name".
+func splitAuthor(a string) (name, email string) {
+	if i, j := strings.IndexByte(a, '<'), strings.LastIndexByte(a, '>'); i >= 0 && j > i {
+		return strings.TrimSpace(a[:i]), strings.TrimSpace(a[i+1 : j])
+	}
+	return strings.TrimSpace(a), ""
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/ardnew/mkgo/exitcode"
+)
+
+// command represents a subcommand of mkgo, selected by the first command-line
+// argument. Each subcommand receives and parses its own remaining arguments.
+type command struct {
+	name   string
+	args   string // synopsis of the arguments accepted
+	usage  string
+	hidden bool // omit from help and completion
+	run    func(arg []string) exitcode.Code
+}
+
+// commands contains every subcommand of mkgo, keyed by name. Subcommands add
+// themselves to this map from init functions via registerCommand.
+var commands = map[string]*command{}
+
+// registerCommand adds the given command cmd to the set of known subcommands.
+func registerCommand(cmd *command) {
+	commands[cmd.name] = cmd
+}
+
+// commandNames returns the sorted names of all subcommands that are not hidden.
+func commandNames() []string {
+	name := []string{}
+	for n, cmd := range commands {
+		if !cmd.hidden {
+			name = append(name, n)
+		}
+	}
+	sort.Strings(name)
+	return name
+}
+
+// usage writes the usage summary of mkgo, its flags followed by each of its
+// subcommands that are not hidden, to the output of flag.CommandLine.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %[1]s:\n  %[1]s [flags] <import-path>\n  %[1]s <command> [arguments]\n\nFlags:\n",
+		flag.CommandLine.Name())
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nCommands:\n")
+	for _, n := range commandNames() {
+		fmt.Fprintf(out, "  %s %s\n    \t%s\n", n, commands[n].args, commands[n].usage)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ardnew/mkgo/exitcode"
+)
+
+func init() {
+	completion = map[string]func(w io.Writer){
+		"bash": bashCompletion,
+		"zsh":  zshCompletion,
+		"fish": fishCompletion,
+	}
+	registerCommand(&command{
+		name:  "completion",
+		args:  strings.Join(completionShells(), "|"),
+		usage: "print shell completion script",
+		run:   runCompletion,
+	})
+	registerCommand(&command{
+		name:   "__complete",
+		args:   "prefix",
+		usage:  "print import paths beginning with prefix",
+		hidden: true,
+		run:    runComplete,
+	})
+}
+
+// completion maps the name of each supported shell to the function that writes
+// its completion script.
+var completion map[string]func(w io.Writer)
+
+// completionValue maps the name of each command-line flag, whose argument is one
+// of a known set of values, to a function returning those values.
+var completionValue = map[string]func() []string{
+	"l":           licenseNames,
+	"fmt":         formatterNames,
+	"deps":        depBotNames,
+	"coverage":    coverageServiceNames,
+	"copyright":   copyrightPolicyNames,
+	"date-format": datePresetNames,
+	"mode":        modeNames,
+	"type":        appTypeNames,
+	"broker":      brokerNames,
+	"t":           templateNames,
+	"log-level":   func() []string { return logLevel },
+	"log-format":  func() []string { return logFormat },
+}
+
+// completionShells returns the sorted names of all supported shells.
+func completionShells() []string {
+	name := []string{}
+	for n := range completion {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+	return name
+}
+
+// runCompletion writes the completion script for the shell named by the first
+// argument to stdout.
+func runCompletion(arg []string) exitcode.Code {
+	if len(arg) != 1 {
+		logger.Error("expected one shell name (options: " +
+			strings.Join(completionShells(), " ") + ")")
+		return exitcode.Usage
+	}
+	write, ok := completion[arg[0]]
+	if !ok {
+		logger.Error("unsupported shell (options: "+
+			strings.Join(completionShells(), " ")+")", "shell", arg[0])
+		return exitcode.Usage
+	}
+	write(os.Stdout)
+	return exitcode.OK
+}
+
+// runComplete writes each candidate import path beginning with the prefix given
+// as first argument to stdout, one per line.
+func runComplete(arg []string) exitcode.Code {
+	prefix := ""
+	if len(arg) > 0 {
+		prefix = arg[0]
+	}
+	for _, c := range completeImport(prefix) {
+		fmt.Println(c)
+	}
+	return exitcode.OK
+}
+
+// completeImport returns the sorted candidate import paths beginning with the
+// given prefix. Candidates are the hosts listed in the configuration file, the
+// existing directories under GOPATH/src, and recently created projects. Each
+// candidate, other than a recent project, ends with a path separator so that
+// it can be completed further.
+func completeImport(prefix string) []string {
+	seen := map[string]bool{}
+	for _, h := range config.Hosts {
+		if h = strings.TrimSuffix(h, "/") + "/"; strings.HasPrefix(h, prefix) {
+			seen[h] = true
+		}
+	}
+	if src := gopathSrc(); src != "" {
+		dir, base := path.Split(prefix)
+		if ent, err := os.ReadDir(filepath.Join(src, filepath.FromSlash(dir))); nil == err {
+			for _, e := range ent {
+				if e.IsDir() && strings.HasPrefix(e.Name(), base) &&
+					!strings.HasPrefix(e.Name(), ".") {
+					seen[dir+e.Name()+"/"] = true
+				}
+			}
+		}
+	}
+	for _, r := range loadRecent() {
+		if strings.HasPrefix(r.Import, prefix) {
+			seen[r.Import] = true
+		}
+	}
+	cand := []string{}
+	for c := range seen {
+		cand = append(cand, c)
+	}
+	sort.Strings(cand)
+	return cand
+}
+
+// isBoolFlag returns whether or not the given flag f accepts no argument.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// flagSummary returns the first line of the given flag f's usage, excluding any
+// parenthetical list of options.
+func flagSummary(f *flag.Flag) string {
+	s := strings.SplitN(f.Usage, "\n", 2)[0]
+	if i := strings.Index(s, " ("); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// bashCompletion writes the bash completion script to w.
+func bashCompletion(w io.Writer) {
+	var flags, value []string
+	var cases strings.Builder
+	flag.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, "-"+f.Name)
+		if values, ok := completionValue[f.Name]; ok {
+			fmt.Fprintf(&cases, "\t-%s)\n", f.Name)
+			fmt.Fprintf(&cases, "\t\tCOMPREPLY=( $(compgen -W %q -- \"${cur}\") )\n",
+				strings.Join(values(), " "))
+			fmt.Fprintf(&cases, "\t\treturn\n\t\t;;\n")
+		} else if !isBoolFlag(f) {
+			value = append(value, "-"+f.Name)
+		}
+	})
+	if len(value) > 0 {
+		fmt.Fprintf(&cases, "\t%s)\n\t\treturn\n\t\t;;\n", strings.Join(value, "|"))
+	}
+	fmt.Fprintf(w, `# bash completion for mkgo
+# load with: source <(mkgo completion bash)
+
+_mkgo_import() {
+	local IFS=$'\n'
+	COMPREPLY+=( $(mkgo __complete "${1}" 2>/dev/null) )
+	compopt -o nospace 2>/dev/null
+}
+
+_mkgo() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local prev="${COMP_WORDS[COMP_CWORD-1]}"
+	COMPREPLY=()
+	if [[ ${COMP_CWORD} -gt 1 && ${COMP_WORDS[1]} == completion ]]; then
+		COMPREPLY=( $(compgen -W %q -- "${cur}") )
+		return
+	fi
+	case "${prev}" in
+%s	esac
+	if [[ ${cur} == -* ]]; then
+		COMPREPLY=( $(compgen -W %q -- "${cur}") )
+		return
+	fi
+	if [[ ${COMP_CWORD} -eq 1 ]]; then
+		COMPREPLY=( $(compgen -W %q -- "${cur}") )
+	fi
+	_mkgo_import "${cur}"
+}
+
+complete -F _mkgo mkgo
+`,
+		strings.Join(completionShells(), " "),
+		cases.String(),
+		strings.Join(flags, " "),
+		strings.Join(commandNames(), " "))
+}
+
+// zshCompletion writes the zsh completion script to w.
+func zshCompletion(w io.Writer) {
+	quote := strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`)
+	var spec strings.Builder
+	flag.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(&spec, "\t\t'-%s[%s]", f.Name, quote.Replace(flagSummary(f)))
+		if values, ok := completionValue[f.Name]; ok {
+			fmt.Fprintf(&spec, ":%s:(%s)", f.Name, strings.Join(values(), " "))
+		} else if !isBoolFlag(f) {
+			fmt.Fprintf(&spec, ":%s: ", f.Name)
+		}
+		fmt.Fprintf(&spec, "' \\\n")
+	})
+	var cmds []string
+	for _, n := range commandNames() {
+		cmds = append(cmds, fmt.Sprintf("'%s:%s'", n, quote.Replace(commands[n].usage)))
+	}
+	fmt.Fprintf(w, `#compdef mkgo
+# load with: source <(mkgo completion zsh)
+
+_mkgo_import() {
+	local -a imports
+	imports=(${(f)"$(mkgo __complete $PREFIX 2>/dev/null)"})
+	compadd -S '' -a imports
+}
+
+_mkgo_first() {
+	local -a cmds
+	cmds=(%s)
+	_describe -t commands 'command' cmds
+	_mkgo_import
+}
+
+_mkgo() {
+	if (( CURRENT > 2 )) && [[ $words[2] == completion ]]; then
+		_values 'shell' %s
+		return
+	fi
+	_arguments -s -S \
+%s		'1: :_mkgo_first'
+}
+
+compdef _mkgo mkgo
+`,
+		strings.Join(cmds, " "),
+		strings.Join(completionShells(), " "),
+		spec.String())
+}
+
+// fishCompletion writes the fish completion script to w.
+func fishCompletion(w io.Writer) {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	fmt.Fprintf(w, `# fish completion for mkgo
+# load with: mkgo completion fish | source
+
+function __mkgo_import
+	mkgo __complete (commandline -ct) 2>/dev/null
+end
+
+complete -c mkgo -f
+`)
+	for _, n := range commandNames() {
+		fmt.Fprintf(w, "complete -c mkgo -n '__fish_use_subcommand' -a '%s' -d '%s'\n",
+			n, quote.Replace(commands[n].usage))
+	}
+	fmt.Fprintf(w, "complete -c mkgo -n '__fish_seen_subcommand_from completion' -a '%s'\n",
+		strings.Join(completionShells(), " "))
+	flag.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(w, "complete -c mkgo -o %s", f.Name)
+		if values, ok := completionValue[f.Name]; ok {
+			fmt.Fprintf(w, " -x -a '%s'", strings.Join(values(), " "))
+		} else if !isBoolFlag(f) {
+			fmt.Fprintf(w, " -x")
+		}
+		fmt.Fprintf(w, " -d '%s'\n", quote.Replace(flagSummary(f)))
+	})
+	fmt.Fprintf(w, "complete -c mkgo -n '__fish_use_subcommand' -a '(__mkgo_import)'\n")
+}
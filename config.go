@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config contains the user's persistent settings for mkgo.
+type Config struct {
+	// Hosts lists import path prefixes (e.g., "github.com/ardnew") offered as
+	// candidates by shell completion.
+	Hosts []string `yaml:"hosts"`
+
+	// DateFormat is the Go time layout or preset name used to format dates
+	// when -date-format is not given.
+	DateFormat string `yaml:"date-format"`
+
+	// Email is the email address of the user when -email is not given.
+	Email string `yaml:"email"`
+
+	// Org is the organization holding the copyright of generated files when
+	// -org is not given.
+	Org string `yaml:"org"`
+
+	// Copyright is the copyright policy (see -copyright) when -copyright is not
+	// given.
+	Copyright string `yaml:"copyright"`
+
+	// Authors lists the authors, in addition to the user, named in AUTHORS and
+	// copyright notices when -author is not given.
+	Authors []string `yaml:"authors"`
+
+	// Plugins lists the names of plugins run, in order, before the files of
+	// every module are rendered. The executable of each plugin is found in PATH
+	// by its name prefixed with "mkgo-plugin-".
+	Plugins []string `yaml:"plugins"`
+
+	// Open is the editor with which every module is opened once created, as
+	// if given with -open, unless -open=false is given. The value true names
+	// the user's preferred editor.
+	Open string `yaml:"open"`
+
+	// TemplatePath lists directories searched, in order, for template sets
+	// given by name with -t, before $XDG_CONFIG_HOME/mkgo/templates.
+	TemplatePath []string `yaml:"template-path"`
+
+	// TemplateKey is the minisign public key, or the path of a file containing
+	// it, that verifies the signatures of template modules when -template-key
+	// is not given.
+	TemplateKey string `yaml:"template-key"`
+
+	// Hooks lists the shell commands run from the directory of every module
+	// before and after its files are written.
+	Hooks Hooks `yaml:"hooks"`
+}
+
+// config contains the settings read from the configuration file by main.
+var config = &Config{}
+
+// configPath returns the default path of the configuration file, config.yaml in
+// the mkgo subdirectory of the user's configuration directory.
+func configPath() string {
+	dir, err := os.UserConfigDir()
+	if nil != err {
+		return ""
+	}
+	return filepath.Join(dir, "mkgo", "config.yaml")
+}
+
+// loadConfig returns the settings read from the configuration file at the given
+// path. A missing configuration file is not an error; the default settings are
+// returned instead.
+func loadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+	b, err := os.ReadFile(path)
+	if nil != err {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, cfg); nil != err {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// coverageService maps the name of each supported coverage reporting service to
+// the path and content of its configuration file (if any), the function that
+// returns its README.md badge, and the GitHub Actions steps uploading a
+// coverage profile to it.
+var coverageService = map[string]struct {
+	path   string
+	tmpl   Template
+	badge  func(imp string) badge
+	upload Template
+}{
+	"codecov": {"codecov.yml", Template{
+		`coverage:`,
+		`  status:`,
+		`    project:`,
+		`      default:`,
+		`        target: auto`,
+		`        threshold: 1%`,
+		`    patch: off`,
+		``,
+		`comment:`,
+		`  layout: "diff, files"`,
+	}, func(imp string) badge {
+		slug := repoSlug(imp, map[string]string{
+			"github.com": "gh", "gitlab.com": "gl", "bitbucket.org": "bb",
+		})
+		return badge{"cov", "Coverage",
+			"https://codecov.io/" + slug + "/graph/badge.svg",
+			"https://codecov.io/" + slug}
+	}, Template{
+		`      - uses: codecov/codecov-action@v4`,
+		`        with:`,
+		`          files: coverage.out`,
+		`          token: ${{"{{"}} secrets.CODECOV_TOKEN }}`,
+	}},
+	"coveralls": {"", nil, func(imp string) badge {
+		slug := repoSlug(imp, map[string]string{
+			"github.com": "github", "gitlab.com": "gitlab", "bitbucket.org": "bitbucket",
+		})
+		return badge{"cov", "Coverage",
+			"https://coveralls.io/repos/" + slug + "/badge.svg",
+			"https://coveralls.io/" + slug}
+	}, Template{
+		`      - uses: coverallsapp/github-action@v2`,
+		`        with:`,
+		`          file: coverage.out`,
+		`          format: golang`,
+	}},
+}
+
+// coverageTarget is the Makefile target that writes the coverage profile.
+var coverageTarget = makeTarget{
+	name: "coverage",
+	help: "write the test coverage profile to coverage.out",
+	recipe: []string{
+		`go test -race -covermode=atomic -coverprofile=coverage.out ./...`,
+	},
+}
+
+// coverageServiceNames returns the sorted names of all supported coverage
+// reporting services.
+func coverageServiceNames() []string {
+	name := []string{}
+	for n := range coverageService {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+	return name
+}
+
+// coverageWorkflowPath is the path of the generated GitHub Actions workflow
+// uploading coverage reports.
+var coverageWorkflowPath = filepath.Join(".github", "workflows", "coverage.yml")
+
+// coverageWorkflow returns the GitHub Actions workflow that runs the Makefile's
+// coverage target and then the given upload steps.
+func coverageWorkflow(upload Template) Template {
+	return append(Template{
+		`name: coverage`,
+		``,
+		`on:`,
+		`  push:`,
+		`    branches: [main]`,
+		`  pull_request:`,
+		``,
+		`jobs:`,
+		`  coverage:`,
+		`    runs-on: ubuntu-latest`,
+		`    steps:`,
+		`      - uses: actions/checkout@v4`,
+		`      - uses: actions/setup-go@v5`,
+		`        with:`,
+		`          go-version-file: go.mod`,
+		`      - run: make coverage`,
+	}, upload...)
+}
+
+// repoSlug returns the given import path imp with its host replaced by the
+// corresponding name in the given map host, and with any components following
+// the repository name removed (e.g., "github.com/ardnew/mkgo/cmd" becomes
+// "gh/ardnew/mkgo" if host maps "github.com" to "gh").
+func repoSlug(imp string, host map[string]string) string {
+	part := strings.Split(imp, "/")
+	if h, ok := host[part[0]]; ok {
+		part[0] = h
+	}
+	if len(part) > 3 {
+		part = part[:3]
+	}
+	return strings.Join(part, "/")
+}
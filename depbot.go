@@ -0,0 +1,58 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// depBot maps the name of each supported dependency update bot to the path and
+// content of its configuration file.
+var depBot = map[string]struct {
+	path string
+	tmpl Template
+}{
+	"dependabot": {filepath.Join(".github", "dependabot.yml"), Template{
+		`version: 2`,
+		`updates:`,
+		`  - package-ecosystem: gomod`,
+		`    directory: /`,
+		`    schedule:`,
+		`      interval: weekly`,
+		`  - package-ecosystem: github-actions`,
+		`    directory: /`,
+		`    schedule:`,
+		`      interval: weekly`,
+	}},
+	"renovate": {"renovate.json", Template{
+		`{`,
+		`	"$schema": "https://docs.renovatebot.com/renovate-schema.json",`,
+		`	"extends": ["config:recommended"],`,
+		`	"postUpdateOptions": ["gomodTidy"]`,
+		`}`,
+	}},
+}
+
+// depBotNames returns the sorted names of all supported dependency update bots,
+// preceded by "auto".
+func depBotNames() []string {
+	name := []string{}
+	for n := range depBot {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+	return append([]string{"auto"}, name...)
+}
+
+// depBotFor returns the name of the dependency update bot selected by the given
+// name. The name "auto" selects Dependabot for modules hosted on GitHub, as
+// identified by the given import path imp, and Renovate for all other hosts.
+func depBotFor(name, imp string) string {
+	if name != "auto" {
+		return name
+	}
+	if strings.HasPrefix(imp, "github.com/") {
+		return "dependabot"
+	}
+	return "renovate"
+}
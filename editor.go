@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// form contains the text collected from the user with their editor.
+type form struct {
+	description []string // lines of the module's description
+	changes     []string // entries of the first changelog revision
+	keywords    []string
+}
+
+// Headers of each section of the form edited by the user.
+const (
+	formDescription = "# Description (package documentation and README):"
+	formChanges     = "# Initial changelog entries, one per line:"
+	formKeywords    = "# Keywords, comma-separated:"
+)
+
+// defaultChanges contains the entries of the first changelog revision if no
+// others are given.
+var defaultChanges = []string{"initial implementation"}
+
+// editorCommand returns the command line of the user's preferred editor, from
+// VISUAL or EDITOR, or vi if neither is set.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if cmd := strings.Fields(os.Getenv(env)); len(cmd) > 0 {
+			return cmd
+		}
+	}
+	return []string{"vi"}
+}
+
+// editForm opens the user's editor with a form describing the module with the
+// given name, and returns the form's content after the editor exits.
+func editForm(name string) (*form, error) {
+	f, err := os.CreateTemp("", "mkgo-"+name+"-*.txt")
+	if nil != err {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(strings.Join([]string{
+		"# Describe module " + name + ". Lines beginning with '#' are ignored.",
+		"",
+		formDescription,
+		"",
+		"",
+		formChanges,
+		strings.Join(defaultChanges, "\n"),
+		"",
+		formKeywords,
+		"",
+	}, "\n"))
+	if errClose := f.Close(); nil == err {
+		err = errClose
+	}
+	if nil != err {
+		return nil, err
+	}
+	cmd := editorCommand()
+	c := exec.Command(cmd[0], append(cmd[1:], f.Name())...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	logger.Debug("running editor", "command", strings.Join(c.Args, " "))
+	if err := c.Run(); nil != err {
+		return nil, err
+	}
+	b, err := os.ReadFile(f.Name())
+	if nil != err {
+		return nil, err
+	}
+	return parseForm(string(b)), nil
+}
+
+// parseForm returns the form whose content is the given text.
+func parseForm(text string) *form {
+	fm := &form{}
+	var section *[]string
+	for _, line := range strings.Split(text, "\n") {
+		switch strings.TrimSpace(line) {
+		case formDescription:
+			section = &fm.description
+			continue
+		case formChanges:
+			section = &fm.changes
+			continue
+		case formKeywords:
+			section = &fm.keywords
+			continue
+		}
+		if section == nil || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch section {
+		case &fm.description:
+			*section = append(*section, strings.TrimRight(line, " \t"))
+		case &fm.changes:
+			if s := strings.TrimSpace(line); s != "" {
+				*section = append(*section, s)
+			}
+		case &fm.keywords:
+			for _, k := range strings.Split(line, ",") {
+				if k = strings.TrimSpace(k); k != "" {
+					*section = append(*section, k)
+				}
+			}
+		}
+	}
+	fm.description = trimBlank(fm.description)
+	if len(fm.changes) == 0 {
+		fm.changes = defaultChanges
+	}
+	return fm
+}
+
+// trimBlank returns the given lines with leading and trailing blank lines
+// removed.
+func trimBlank(line []string) []string {
+	for len(line) > 0 && line[0] == "" {
+		line = line[1:]
+	}
+	for len(line) > 0 && line[len(line)-1] == "" {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// docLines returns the receiver form's description as lines of a Go comment.
+func (fm *form) docLines() []string {
+	doc := []string{}
+	for _, d := range fm.description {
+		doc = append(doc, strings.TrimRight("// "+d, " "))
+	}
+	return doc
+}
+
+// readmeLines returns the receiver form's description as lines of README.md,
+// followed by a blank line if not empty.
+func (fm *form) readmeLines() []string {
+	if len(fm.description) == 0 {
+		return nil
+	}
+	return append(append([]string{}, fm.description...), "")
+}
+
+// changeLines returns the receiver form's changelog entries as quoted Go
+// string literals.
+func (fm *form) changeLines() []string {
+	quoted := []string{}
+	for _, c := range fm.changes {
+		quoted = append(quoted, strconv.Quote(c))
+	}
+	return quoted
+}
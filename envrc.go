@@ -0,0 +1,25 @@
+package main
+
+// envrc returns the direnv configuration that installs executables into the
+// project's bin directory and adds it to PATH, followed by the given export
+// statements exports, if any, for project-specific environment variables.
+func envrc(exports ...string) Template {
+	if len(exports) > 0 {
+		exports = append([]string{``, `# environment variables read by __NAME__`}, exports...)
+	}
+	return append(Template{
+		`# direnv configuration for __NAME__.`,
+		`# Review any changes to this file, and then run "direnv allow" to load it.`,
+		``,
+		`export GOBIN="${PWD}/bin"`,
+		`PATH_add "${GOBIN}"`,
+		``,
+		`# flags passed to every go command, e.g. "-trimpath -tags=integration"`,
+		`export GOFLAGS=""`,
+	}, append(exports,
+		``,
+		`# machine-specific settings that should not be committed`,
+		`source_env_if_exists .envrc.local`,
+		``,
+	)...)
+}
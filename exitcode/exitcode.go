@@ -0,0 +1,95 @@
+// Package exitcode defines the exit status of mkgo for each category of error.
+// The numeric value of each Code is stable across releases, so that scripts
+// and CI wrappers may depend on them.
+package exitcode
+
+import "strconv"
+
+// Code is the exit status of mkgo.
+type Code int
+
+// Constants for every exit status of mkgo. New codes are only ever appended;
+// existing codes are never renumbered or reused.
+const (
+	OK           Code = 0  // success
+	Usage        Code = 1  // invalid command-line arguments
+	CreateDir    Code = 2  // cannot create the package directory
+	SourceIsDir  Code = 3  // the Go source file path is a directory
+	SourceWrite  Code = 4  // cannot write the Go source file
+	Format       Code = 5  // the formatter failed on the Go source file
+	ModInit      Code = 6  // "go mod init" failed
+	SourceExists Code = 7  // the Go source file exists (use -f)
+	License      Code = 8  // unsupported license
+	DocIsDir     Code = 9  // an auxiliary file path is a directory
+	DocWrite     Code = 10 // cannot write an auxiliary file
+	DocExists    Code = 11 // an auxiliary file exists (use -f)
+	Network      Code = 12 // cannot query a remote service
+	Install      Code = 13 // cannot install or verify an update
+	Config       Code = 14 // cannot read the configuration file
+	Plan         Code = 15 // cannot read or write a plan file
+	Stale        Code = 16 // the environment does not match a saved plan
+	Plugin       Code = 17 // a plugin failed
+	Hook         Code = 18 // a hook command failed
+	Template     Code = 19 // cannot load template set
+	Require      Code = 20 // cannot add a module required by the template set
+	Editor       Code = 21 // the editor failed
+	Unsafe       Code = 22 // refused to create a module in a dangerous destination
+	Verify       Code = 23 // cannot verify a template module
+	Generate     Code = 24 // "go generate" failed
+	Conflict     Code = 25 // merge conflicts remain in a merged file
+)
+
+// Table lists every exit status of mkgo, in numeric order, with the name and a
+// description of the category of error it represents.
+var Table = []struct {
+	Code        Code
+	Name        string
+	Description string
+}{
+	{OK, "ok", "success"},
+	{Usage, "usage", "invalid command-line arguments"},
+	{CreateDir, "create-dir", "cannot create the package directory"},
+	{SourceIsDir, "source-is-dir", "the Go source file path is a directory"},
+	{SourceWrite, "source-write", "cannot write the Go source file"},
+	{Format, "format", "the formatter failed on the Go source file"},
+	{ModInit, "mod-init", "go mod init failed"},
+	{SourceExists, "source-exists", "the Go source file already exists"},
+	{License, "license", "unsupported license"},
+	{DocIsDir, "doc-is-dir", "an auxiliary file (LICENSE, README.md, etc.) path is a directory"},
+	{DocWrite, "doc-write", "cannot write an auxiliary file (LICENSE, README.md, etc.)"},
+	{DocExists, "doc-exists", "an auxiliary file (LICENSE, README.md, etc.) already exists"},
+	{Network, "network", "cannot query a remote service"},
+	{Install, "install", "cannot install or verify an update"},
+	{Config, "config", "cannot read the configuration file"},
+	{Plan, "plan", "cannot read or write a plan file"},
+	{Stale, "stale", "the environment does not match a saved plan"},
+	{Plugin, "plugin", "a plugin failed"},
+	{Hook, "hook", "a hook command failed"},
+	{Template, "template", "cannot load template set"},
+	{Require, "require", "cannot add a module required by the template set"},
+	{Editor, "editor", "the editor failed"},
+	{Unsafe, "unsafe", "refused to create a module in a dangerous destination"},
+	{Verify, "verify", "cannot verify a template module"},
+	{Generate, "generate", "go generate failed"},
+	{Conflict, "conflict", "merge conflicts remain in a merged file"},
+}
+
+// String returns the name of the receiver's category of error.
+func (c Code) String() string {
+	for _, e := range Table {
+		if e.Code == c {
+			return e.Name
+		}
+	}
+	return "code-" + strconv.Itoa(int(c))
+}
+
+// Description returns a description of the receiver's category of error.
+func (c Code) Description() string {
+	for _, e := range Table {
+		if e.Code == c {
+			return e.Description
+		}
+	}
+	return "unknown error"
+}
@@ -0,0 +1,88 @@
+package exitcode
+
+import "testing"
+
+func TestCode(t *testing.T) {
+	// the numeric value and name of each Code are stable across releases.
+	tests := []struct {
+		code Code
+		num  int
+		name string
+	}{
+		{OK, 0, "ok"},
+		{Usage, 1, "usage"},
+		{CreateDir, 2, "create-dir"},
+		{SourceIsDir, 3, "source-is-dir"},
+		{SourceWrite, 4, "source-write"},
+		{Format, 5, "format"},
+		{ModInit, 6, "mod-init"},
+		{SourceExists, 7, "source-exists"},
+		{License, 8, "license"},
+		{DocIsDir, 9, "doc-is-dir"},
+		{DocWrite, 10, "doc-write"},
+		{DocExists, 11, "doc-exists"},
+		{Network, 12, "network"},
+		{Install, 13, "install"},
+		{Config, 14, "config"},
+		{Plan, 15, "plan"},
+		{Stale, 16, "stale"},
+		{Plugin, 17, "plugin"},
+		{Hook, 18, "hook"},
+		{Template, 19, "template"},
+		{Require, 20, "require"},
+		{Editor, 21, "editor"},
+		{Unsafe, 22, "unsafe"},
+		{Verify, 23, "verify"},
+		{Generate, 24, "generate"},
+		{Conflict, 25, "conflict"},
+	}
+	if len(tests) != len(Table) {
+		t.Errorf("len(Table) = %d, want %d", len(Table), len(tests))
+	}
+	for _, tt := range tests {
+		if int(tt.code) != tt.num {
+			t.Errorf("Code %s = %d, want %d", tt.name, int(tt.code), tt.num)
+		}
+		if got := tt.code.String(); got != tt.name {
+			t.Errorf("Code(%d).String() = %q, want %q", tt.num, got, tt.name)
+		}
+		if got := tt.code.Description(); got == "" || got == "unknown error" {
+			t.Errorf("Code(%d).Description() = %q, want its description", tt.num, got)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	seen := map[string]bool{}
+	for i, e := range Table {
+		if int(e.Code) != i {
+			t.Errorf("Table[%d].Code = %d, want %d (in numeric order without gaps)", i, int(e.Code), i)
+		}
+		if seen[e.Name] {
+			t.Errorf("Table[%d].Name = %q, duplicate", i, e.Name)
+		}
+		seen[e.Name] = true
+		if e.Description == "" {
+			t.Errorf("Table[%d].Description is empty", i)
+		}
+	}
+}
+
+func TestUnknownCode(t *testing.T) {
+	tests := []struct {
+		code Code
+		name string
+	}{
+		{-1, "code--1"},
+		{255, "code-255"},
+		{1000, "code-1000"},
+	}
+	for _, tt := range tests {
+		if got := tt.code.String(); got != tt.name {
+			t.Errorf("Code(%d).String() = %q, want %q", int(tt.code), got, tt.name)
+		}
+		if got := tt.code.Description(); got != "unknown error" {
+			t.Errorf("Code(%d).Description() = %q, want %q", int(tt.code), got, "unknown error")
+		}
+	}
+}
@@ -1,5 +1,14 @@
 module github.com/ardnew/mkgo
 
-go 1.16
+go 1.21
 
-require github.com/ardnew/version v0.2.0
+require (
+	github.com/ardnew/version v0.2.0
+	go.starlark.net v0.0.0-20240123142251-f86470692795
+	golang.org/x/crypto v0.17.0
+	golang.org/x/mod v0.14.0
+	golang.org/x/term v0.15.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.15.0 // indirect
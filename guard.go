@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// dangerous returns a description of why creating a module in the given
+// directory dir is dangerous, or the empty string if it is not. Creating a
+// module in an existing, non-empty repository is dangerous only if the given
+// allowRepo is false.
+func dangerous(dir string, allowRepo bool) string {
+	dir = filepath.Clean(dir)
+	if dir == filepath.VolumeName(dir)+string(filepath.Separator) {
+		return "file system root"
+	}
+	if home, err := os.UserHomeDir(); nil == err && dir == filepath.Clean(home) {
+		return "home directory"
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		gopath = build.Default.GOPATH
+	}
+	for _, p := range filepath.SplitList(gopath) {
+		if p = filepath.Clean(p); dir == p || dir == filepath.Join(p, "src") {
+			return "GOPATH directory"
+		}
+	}
+	if !allowRepo {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); nil == err {
+			if ent, err := os.ReadDir(dir); nil == err && len(ent) > 1 {
+				return "existing non-empty repository (use -here to allow)"
+			}
+		}
+	}
+	return ""
+}
+
+// stdin reads the answers of the user to the prompts of confirm and ask.
+var stdin = bufio.NewReader(os.Stdin)
+
+// interactive returns whether or not stdin is a terminal, unlike, e.g.,
+// /dev/null, which is also a character device.
+func interactive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// confirm asks the user, if stdin is a terminal, whether or not to proceed
+// with the action described by the given prompt, and returns their answer.
+// Returns false if stdin is not a terminal.
+func confirm(prompt string) bool {
+	if !interactive() {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "mkgo: %s? [y/N] ", prompt)
+	line, _ := stdin.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// ask asks the user, if stdin is a terminal, for the value described by the
+// given prompt, and returns their answer, without surrounding space, and
+// whether or not one was given. Returns false if stdin is not a terminal or is
+// closed before a line is read.
+func ask(prompt string) (string, bool) {
+	if !interactive() {
+		return "", false
+	}
+	fmt.Fprintf(os.Stderr, "mkgo: %s: ", prompt)
+	line, err := stdin.ReadString('\n')
+	if nil != err && line == "" {
+		fmt.Fprintln(os.Stderr)
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ardnew/mkgo/exitcode"
+)
+
+func init() {
+	registerCommand(&command{
+		name:  "spdx",
+		args:  "-l license [-holder name] [-d date] [-n] [dir]",
+		usage: "add the SPDX license identifier to the Go source files of an existing project",
+		run:   runSPDX,
+	})
+}
+
+var (
+	// spdxHeader matches the SPDX license identifier of a Go source file.
+	spdxHeader = regexp.MustCompile(`(?m)^\s*//\s*SPDX-License-Identifier:`)
+	// generatedSource matches the comment marking a generated Go source file,
+	// which is never edited.
+	generatedSource = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+)
+
+// runSPDX begins each Go source file in the directory given as argument, or
+// the current directory, and its subdirectories with the SPDX identifier of the
+// license given with -l and, if -holder is given, a copyright notice, as with
+// -spdx and -copyright-header. Files that already have an SPDX
+// identifier or are generated are left unchanged, as are those in directories
+// ignored by the go command: vendor, testdata, and those beginning with "." or
+// "_".
+func runSPDX(arg []string) exitcode.Code {
+	var argLicense, argHolder, argDate string
+	var argDryRun bool
+	fs := flag.NewFlagSet("spdx", flag.ContinueOnError)
+	fs.StringVar(&argLicense, "l", "", "SPDX identifier, or file:path of a custom license, of the project's license (comma-separated if several)")
+	fs.StringVar(&argHolder, "holder", "", "copyright holder of the copyright notice following the identifier")
+	fs.StringVar(&argDate, "d", "", "date of the copyright notice (default: today)")
+	fs.BoolVar(&argDryRun, "n", false, "only list the files that would be changed")
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if argLicense == "" {
+		logger.Error("no license given (use -l)")
+		return exitcode.Usage
+	}
+	if fs.NArg() > 1 {
+		logger.Error("expected at most one project directory (use -h for help)")
+		return exitcode.Usage
+	}
+	root := "."
+	if fs.NArg() == 1 {
+		root = fs.Arg(0)
+	}
+	spdx := []string{}
+	for _, id := range strings.Split(argLicense, ",") {
+		id = strings.TrimSpace(id)
+		if strings.HasPrefix(id, licenseFilePrefix) {
+			id = licenseRef(strings.TrimPrefix(id, licenseFilePrefix))
+		}
+		if !spdxID.MatchString(id) {
+			logger.Error("invalid SPDX license identifier", "license", id)
+			return exitcode.License
+		}
+		spdx = append(spdx, id)
+	}
+	header := "// SPDX-License-Identifier: " + strings.Join(spdx, " OR ") + "\n"
+	if argHolder != "" {
+		header += "// Copyright (c) " + dateYear(argDate) + " " + argHolder + "\n"
+	}
+	header += "\n"
+
+	count := 0
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if nil != err {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if p != root && (name == "vendor" || name == "testdata" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(p) != ".go" || !d.Type().IsRegular() {
+			return nil
+		}
+		b, err := os.ReadFile(p)
+		if nil != err {
+			return err
+		}
+		// only the comments preceding the package clause are considered.
+		head := b
+		if i := bytes.Index(append([]byte("\n"), b...), []byte("\npackage ")); i >= 0 {
+			head = b[:i]
+		}
+		if spdxHeader.Match(head) || generatedSource.Match(head) {
+			return nil
+		}
+		count++
+		if argDryRun {
+			logger.Info("would add license header", "path", p)
+			return nil
+		}
+		info, err := d.Info()
+		if nil != err {
+			return err
+		}
+		logger.Info("added license header", "path", p)
+		return os.WriteFile(p, append([]byte(header), b...), info.Mode().Perm())
+	})
+	if nil != err {
+		logger.Error("cannot add license header", "path", root, "error", err)
+		return exitcode.SourceWrite
+	}
+	if argDryRun {
+		logger.Info("found files without license header", "files", count)
+	} else {
+		logger.Info("added license headers", "files", count)
+	}
+	return exitcode.OK
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/ardnew/mkgo/exitcode"
+)
+
+// Hooks lists the shell commands run before and after the files of a module
+// are written.
+type Hooks struct {
+	Pre  []string `yaml:"pre" json:"pre,omitempty"`
+	Post []string `yaml:"post" json:"post,omitempty"`
+}
+
+// hookEnvPrefix is the prefix of the name of each environment variable, whose
+// value is a substitution of the module, exported to hooks.
+const hookEnvPrefix = "MKGO_"
+
+// hookShell returns the command line of the shell running each hook, followed
+// by the hook.
+func hookShell() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/c"}
+	}
+	return []string{"sh", "-c"}
+}
+
+// runHooks runs each of the given shell commands, in order, from the given
+// directory dir with each of the given substitutions vars exported as an
+// environment variable, recording each in the given summary. Any failure is
+// logged, and the exit status of mkgo is returned.
+func runHooks(dir string, vars map[string]string, hook []string, sum *Summary) exitcode.Code {
+	env := hookEnv(vars)
+	for _, h := range hook {
+		sh := hookShell()
+		c := exec.Command(sh[0], append(sh[1:], h)...)
+		c.Dir = dir
+		c.Env = env
+		logger.Debug("running hook", "command", h, "dir", dir)
+		start := time.Now()
+		out, err := c.CombinedOutput()
+		sum.addCommand(c.Args, start, nil != err)
+		if nil != err {
+			logger.Error("hook failed", "command", h, "error", err, "output", string(out))
+			return exitcode.Hook
+		}
+	}
+	return exitcode.OK
+}
+
+// hookEnv returns the environment of hooks: that of mkgo with each of the
+// given substitutions vars exported as an environment variable prefixed with
+// hookEnvPrefix.
+func hookEnv(vars map[string]string) []string {
+	env := os.Environ()
+	key := []string{}
+	for k := range vars {
+		key = append(key, k)
+	}
+	sort.Strings(key)
+	for _, k := range key {
+		env = append(env, hookEnvPrefix+k+"="+vars[k])
+	}
+	return env
+}
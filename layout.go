@@ -0,0 +1,81 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// versionPath is the path, relative to the module, of the package shared by
+// every command of the cmd/ layout, which defines the version and changelog.
+var versionPath = filepath.Join("internal", "version", "version.go")
+
+// cmdPath returns the path, relative to the module, of the main package of the
+// command with the given name in the cmd/ layout.
+func cmdPath(name string) string {
+	return filepath.Join("cmd", name, "main.go")
+}
+
+// cmdFiles returns the files of the cmd/ layout, consisting of a main package
+// for each of the commands with the given names and the package, located at
+// versionPath, defining the version and changelog they share.
+func cmdFiles(name []string) []fileSpec {
+	spec := []fileSpec{{versionPath, "source", 0664, versionTemplate, nil}}
+	for _, n := range name {
+		tmpl := cmdTemplate.expand("__CMD__", []string{n})
+		spec = append(spec, fileSpec{cmdPath(n), "source", 0664, tmpl, nil})
+	}
+	return spec
+}
+
+// cmdLaunch returns the given VS Code launch configuration tmpl with its
+// program changed to the main package of the command with the given name.
+func cmdLaunch(tmpl Template, name string) Template {
+	out := append(Template{}, tmpl...)
+	for i, s := range out {
+		out[i] = strings.Replace(s, `"${workspaceFolder}"`,
+			`"${workspaceFolder}/`+filepath.ToSlash(filepath.Dir(cmdPath(name)))+`"`, 1)
+	}
+	return out
+}
+
+// libPackage returns the name of the library package created by -lib in the
+// directory with the given name: the name without any "go-" prefix or "-go"
+// suffix, in lower case, and without the characters not allowed in a package
+// name.
+func libPackage(name string) string {
+	name = strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(name), "go-"), "-go")
+	pkg := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return -1
+	}, name)
+	if pkg == "" || pkg[0] >= '0' && pkg[0] <= '9' {
+		pkg = "lib" + pkg
+	}
+	return pkg
+}
+
+// libFiles returns the files of the library package with the given name pkg
+// created by -lib: its documentation in doc.go, an exported example function,
+// and its test.
+func libFiles(pkg string) []fileSpec {
+	file := pkg
+	if file == "doc" {
+		file = "lib"
+	}
+	return []fileSpec{
+		{"doc.go", "source", 0664, libDocTemplate, nil},
+		{file + ".go", "source", 0664, libTemplate, nil},
+		{file + "_test.go", "source", 0664, libTestTemplate, nil},
+	}
+}
+
+var (
+	versionTemplate = builtin.must("version.go")
+	cmdTemplate     = builtin.must("cmd.go")
+	libDocTemplate  = builtin.must("lib/doc.go")
+	libTemplate     = builtin.must("lib/lib.go")
+	libTestTemplate = builtin.must("lib/lib_test.go")
+	libReadme       = builtin.must("lib/README.md")
+)
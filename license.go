@@ -0,0 +1,340 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ardnew/mkgo/exitcode"
+	"github.com/ardnew/mkgo/scaffold"
+)
+
+func init() {
+	registerCommand(&command{
+		name:  "license",
+		args:  "[-holder name] [-copyright policy] [-org name] [-copyright-years years] [-f] <license> [dir]",
+		usage: "add or replace the license of an existing project",
+		run:   runLicense,
+	})
+}
+
+// licenseFilePrefix prefixes the path of a file given with -l containing the
+// text of a custom license, e.g., a proprietary one.
+const licenseFilePrefix = "file:"
+
+// licenseRefInvalid matches the characters that cannot occur in the idstring
+// of an SPDX license reference.
+var licenseRefInvalid = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// loadLicense returns the LICENSE Template of the license with the given id,
+// as given with -l: a built-in license, the file:path of a custom license, or
+// any other license in the SPDX license list. Its SPDX identifier is also
+// returned. Any failure is logged, and the exit status of mkgo is returned.
+func loadLicense(id string) (Template, string, exitcode.Code) {
+	if tmpl, ok := licenseTemplate[id]; ok {
+		return tmpl, id, exitcode.OK
+	}
+	if path, ok := strings.CutPrefix(id, licenseFilePrefix); ok {
+		tmpl, ref, err := fileLicense(path)
+		if nil != err {
+			logger.Error("cannot read license", "error", err)
+			return nil, "", exitcode.License
+		}
+		return tmpl, ref, exitcode.OK
+	}
+	tmpl, err := spdxLicense(id)
+	if nil != err {
+		if errors.Is(err, errUnknownLicense) {
+			logger.Error("unsupported license (use -h to view options)", "license", id)
+			return nil, "", exitcode.License
+		}
+		logger.Error("cannot fetch license", "license", id, "error", err)
+		return nil, "", exitcode.Network
+	}
+	return tmpl, id, exitcode.OK
+}
+
+// licensePlaceholders are the names of the placeholder tokens replaced in the
+// text of a custom license, those defined by both mkgo and mkgo license.
+var licensePlaceholders = []string{"EMAIL", "HOLDER", "IMPORT", "LICENSE", "NAME", "ORG", "USER", "YEAR"}
+
+// fileLicense returns the LICENSE Template of the custom license whose text is
+// in the file at the given path, and its SPDX license reference. The text is
+// rendered as is, except for its licensePlaceholders.
+func fileLicense(path string) (tmpl Template, ref string, err error) {
+	b, err := os.ReadFile(path)
+	if nil != err {
+		return nil, "", err
+	}
+	text := strings.TrimSuffix(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+	text = scaffold.QuoteTokens(scaffold.Escape(text))
+	for _, name := range licensePlaceholders {
+		text = strings.ReplaceAll(text, "__!"+name+"__", "__"+name+"__")
+	}
+	return strings.Split(text, "\n"), licenseRef(path), nil
+}
+
+// licenseRef returns the SPDX license reference of the custom license in the
+// file at the given path, "LicenseRef-" followed by the name of the file
+// without its extension.
+func licenseRef(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	id := strings.Trim(licenseRefInvalid.ReplaceAllString(name, "-"), "-")
+	if id == "" {
+		id = "custom"
+	}
+	return "LicenseRef-" + id
+}
+
+// licenseVersion matches the version, if any, at the end of an SPDX license
+// identifier.
+var licenseVersion = regexp.MustCompile(`(-[0-9][0-9.]*)?(-only|-or-later)?$`)
+
+// licenseSuffix returns the suffix of the file LICENSE-suffix of the license
+// with the given SPDX identifier ref, one of several given with -l, by the
+// convention of its identifier in upper case without its version, e.g.,
+// LICENSE-APACHE for Apache-2.0, unless that is the path of one of the given
+// files doc of the other licenses.
+func licenseSuffix(ref string, doc []fileSpec) string {
+	id := strings.TrimPrefix(ref, "LicenseRef-")
+	short := strings.ToUpper(licenseVersion.ReplaceAllString(id, ""))
+	id = strings.ToUpper(id)
+	for _, d := range doc {
+		if d.path == "LICENSE-"+short {
+			return id
+		}
+	}
+	if short == "" {
+		return id
+	}
+	return short
+}
+
+// readmeLicense returns the section of README.md describing the terms of the
+// licenses with the given SPDX identifiers spdx, written to the given files
+// doc, if there are several, from which users choose the one that applies.
+func readmeLicense(spdx []string, doc []fileSpec) Template {
+	if len(doc) < 2 {
+		return nil
+	}
+	either := "either"
+	if len(doc) > 2 {
+		either = "any"
+	}
+	tmpl := Template{``, `## License`, ``, `Licensed under ` + either + ` of`, ``}
+	for i, d := range doc {
+		tmpl = append(tmpl, " * `"+spdx[i]+"` (["+d.path+"]("+d.path+"))")
+	}
+	return append(tmpl, ``, `at your option.`, ``,
+		`Unless you explicitly state otherwise, any contribution intentionally`,
+		`submitted for inclusion in the work by you shall be licensed as above,`,
+		`without any additional terms or conditions.`)
+}
+
+// licenseChooser is given with -l to choose the license interactively.
+const licenseChooser = "?"
+
+// chooseLicense asks the user, if stdin is a terminal, to choose one of the
+// built-in licenses, listed with their descriptions, any of which may first be
+// previewed with the given substitutions vars and copyright holders. Any other
+// license is chosen by its SPDX identifier. The chosen license, or "none", is
+// returned with whether or not one was chosen before stdin was closed.
+func chooseLicense(vars map[string]string, holder []string) (string, bool) {
+	if !interactive() {
+		return "", false
+	}
+	name := licenseNames()
+	fmt.Fprintln(os.Stderr, "mkgo: licenses:")
+	for i, n := range name {
+		fmt.Fprintf(os.Stderr, "  %2d  %-18s  %s\n", i+1, n, licenseDescription[n])
+	}
+	fmt.Fprintf(os.Stderr, "  %2d  %-18s  %s\n", 0, "none", "no license")
+	for {
+		val, ok := ask("license (number or SPDX identifier, ?number to preview)")
+		if !ok {
+			return "", false
+		}
+		num, view := strings.CutPrefix(val, "?")
+		i, err := strconv.Atoi(num)
+		switch {
+		case val == "":
+			continue
+		case nil != err && !view:
+			return val, true
+		case nil != err || i < 0 || i > len(name):
+			fmt.Fprintf(os.Stderr, "mkgo: no license numbered %s\n", num)
+		case view && i > 0:
+			previewLicense(name[i-1], vars, holder)
+		case view:
+		case i == 0:
+			return "none", true
+		default:
+			return name[i-1], true
+		}
+	}
+}
+
+// previewLicense writes the text of the built-in license with the given name,
+// with the given substitutions vars and copyright holders, to $PAGER, if set,
+// or else to stderr.
+func previewLicense(name string, vars map[string]string, holder []string) {
+	tmpl := licenseTemplate[name].expand("__HOLDER__", holder)
+	if err := tmpl.insert(vars, nil); nil != err {
+		tmpl = licenseTemplate[name]
+	}
+	text := tmpl.String() + "\n"
+	if pager := strings.Fields(os.Getenv("PAGER")); len(pager) > 0 {
+		cmd := exec.Command(pager[0], pager[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = strings.NewReader(text), os.Stdout, os.Stderr
+		if err := cmd.Run(); nil == err {
+			return
+		}
+	}
+	fmt.Fprint(os.Stderr, text)
+}
+
+// runLicense writes the license given as argument, as given with -l, to the
+// project in the directory given as argument, or the current directory, at
+// the path of its convention, e.g., LICENSE or COPYING. Its copyright holder is
+// the one given with -holder or, if not given, those of a new module, and its
+// year is that of the first commit of the project's repository.
+func runLicense(arg []string) exitcode.Code {
+	var argHolder, argYears string
+	var argForce bool
+	fs := flag.NewFlagSet("license", flag.ContinueOnError)
+	opt := &options{flags: fs}
+	fs.StringVar(&argHolder, "holder", "", "copyright holder, instead of those of the copyright policy")
+	fs.StringVar(&opt.copyright, "copyright", "", "copyright policy (options: "+strings.Join(copyrightPolicyNames(), " ")+")")
+	fs.StringVar(&opt.org, "org", "", "organization holding the copyright, if not the authors")
+	fs.StringVar(&argYears, "copyright-years", "", "`years` of the copyright notice, a year or range such as 2018-2025 (default: year of the first commit)")
+	fs.BoolVar(&argForce, "f", false, "force replacing an existing license file")
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		logger.Error("expected a license and at most one project directory (use -h for help)")
+		return exitcode.Usage
+	}
+	id, dir := fs.Arg(0), "."
+	if fs.NArg() == 2 {
+		dir = fs.Arg(1)
+	}
+	// unlike -l, the license of a project is not chosen interactively, and a
+	// project has exactly one.
+	if id == "none" || id == licenseChooser || strings.Contains(id, ",") {
+		logger.Error("expected a single license (use -h for help)", "license", id)
+		return exitcode.Usage
+	}
+	if info, err := os.Stat(dir); nil != err || !info.IsDir() {
+		logger.Error("project is not a directory", "path", dir)
+		return exitcode.Usage
+	}
+	tmpl, ref, code := loadLicense(id)
+	if code != exitcode.OK {
+		return code
+	}
+	opt.years, opt.date = argYears, firstCommitDate(dir)
+	year, ok := opt.copyrightYear()
+	if !ok {
+		logger.Error("invalid copyright years (use a year or range first-last)", "years", argYears)
+		return exitcode.Usage
+	}
+	imp := scaffold.ModulePath(os.DirFS(dir))
+	name := filepath.Base(dir)
+	if abs, err := filepath.Abs(dir); nil == err {
+		name = filepath.Base(abs)
+	}
+	if imp != "" {
+		repo, _ := splitMajor(imp)
+		_, name = packagePath(repo)
+	}
+	opt.user = gitConfig(dir, "user.name")
+	if opt.user == "" {
+		opt.user = os.Getenv("USER")
+	}
+	holder := []string{argHolder}
+	if argHolder == "" {
+		var err error
+		if holder, err = opt.holders(name); nil != err {
+			logger.Error("cannot determine copyright holders", "error", err)
+			return exitcode.Usage
+		}
+	}
+	vars := map[string]string{
+		"IMPORT":  imp,
+		"NAME":    name,
+		"YEAR":    year,
+		"USER":    opt.user,
+		"EMAIL":   gitConfig(dir, "user.email"),
+		"ORG":     opt.organization(),
+		"HOLDER":  strings.Join(holder, ", "),
+		"LICENSE": ref,
+	}
+
+	file := []struct {
+		path, id string
+		tmpl     Template
+	}{{"LICENSE", ref, tmpl}}
+	if f, ok := licensePath[id]; ok {
+		file[0].path = f
+	}
+	// the GNU Lesser General Public License supplements the GNU General Public
+	// License, which must be distributed along with it.
+	if id == "LGPL-3.0-or-later" {
+		file = append(file, file[0])
+		file[1].path, file[1].id = "COPYING", "GPL-3.0-or-later"
+		file[1].tmpl = licenseTemplate[file[1].id]
+	}
+	for _, f := range file {
+		full := filepath.Join(dir, f.path)
+		exists, isDir := fileExists(full)
+		if isDir {
+			logger.Error("output file is a directory", "path", full)
+			return exitcode.DocIsDir
+		}
+		if exists && !argForce {
+			logger.Error("file exists (use -f to overwrite)", "path", full)
+			return exitcode.DocExists
+		}
+		body := f.tmpl.expand("__HOLDER__", holder)
+		if err := body.insert(vars, nil); nil != err {
+			logger.Error("cannot render template", "path", f.path, "error", err)
+			return exitcode.Template
+		}
+		if err := os.WriteFile(full, []byte(body.file()), 0664); nil != err {
+			logger.Error("cannot write file", "path", full, "error", err)
+			return exitcode.DocWrite
+		}
+		logger.Info("wrote license", "path", full, "license", f.id)
+	}
+	for _, other := range []string{"LICENSE", "COPYING", "COPYING.LESSER"} {
+		written := false
+		for _, f := range file {
+			written = written || f.path == other
+		}
+		if exists, _ := fileExists(filepath.Join(dir, other)); exists && !written {
+			logger.Warn("another license file remains", "path", filepath.Join(dir, other))
+		}
+	}
+	return exitcode.OK
+}
+
+// firstCommitDate returns the date, in ISO 8601 format, of the first commit of
+// the git repository containing the given directory dir, or the empty string
+// if it has none.
+func firstCommitDate(dir string) string {
+	cmd := exec.Command("git", "log", "--reverse", "--format=%as")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if nil != err {
+		return ""
+	}
+	first, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimSpace(first)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileLicense(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "company-license.txt")
+	text := "Copyright __YEAR__ __HOLDER__\n{{.NAME}} __X__ __NAME|upper__ __!NAME__ __NAME__\n"
+	if err := os.WriteFile(path, []byte(text), 0664); nil != err {
+		t.Fatal(err)
+	}
+	tmpl, ref, err := fileLicense(path)
+	if nil != err {
+		t.Fatalf("fileLicense() = %v", err)
+	}
+	if ref != "LicenseRef-company-license" {
+		t.Errorf("fileLicense() ref = %q, want LicenseRef-company-license", ref)
+	}
+	// only the license placeholders are replaced; actions and other tokens
+	// are written as is.
+	vars := map[string]string{"YEAR": "2026", "HOLDER": "Acme", "NAME": "app", "X": "x"}
+	if err := tmpl.insert(vars, nil); nil != err {
+		t.Fatalf("insert() = %v", err)
+	}
+	want := "Copyright 2026 Acme\n{{.NAME}} __X__ __NAME|upper__ __!NAME__ app\n"
+	if got := tmpl.file(); got != want {
+		t.Errorf("fileLicense() rendered %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// logger receives all progress and failure messages of mkgo. It is configured
+// from the -log-level and -log-format command-line flags by configureLogger.
+var logger = slog.New(newTextHandler(os.Stderr, slog.LevelInfo))
+
+// logLevel and logFormat contain the names of all supported log levels and log
+// formats, respectively.
+var (
+	logLevel  = []string{"debug", "info", "warn", "error"}
+	logFormat = []string{"text", "json"}
+)
+
+// configureLogger replaces logger with one writing messages at or above the
+// given level using the given format.
+func configureLogger(level, format string) error {
+	var lev slog.Level
+	if err := lev.UnmarshalText([]byte(level)); nil != err {
+		return fmt.Errorf("invalid log level (options: %s): %s",
+			strings.Join(logLevel, " "), level)
+	}
+	switch format {
+	case "text":
+		logger = slog.New(newTextHandler(os.Stderr, lev))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: lev}))
+	default:
+		return fmt.Errorf("invalid log format (options: %s): %s",
+			strings.Join(logFormat, " "), format)
+	}
+	return nil
+}
+
+// textHandler is a slog.Handler that writes each record as a single line of
+// human-readable text, prefixed by its severity, with the value of each of its
+// attributes appended in order.
+type textHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+// newTextHandler returns a textHandler writing records at or above the given
+// level to w.
+func newTextHandler(w io.Writer, level slog.Leveler) *textHandler {
+	return &textHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+// Enabled returns whether or not the receiver handles records with the given
+// level.
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle writes the given record r to the receiver's writer.
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString("error: ")
+	case r.Level >= slog.LevelWarn:
+		b.WriteString("warning: ")
+	case r.Level >= slog.LevelInfo:
+		b.WriteString("mkgo: ")
+	default:
+		b.WriteString("debug: ")
+	}
+	b.WriteString(r.Message)
+	value := func(a slog.Attr) bool {
+		if s := a.Value.String(); s != "" {
+			b.WriteString(": ")
+			b.WriteString(strings.TrimRight(s, "\n"))
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		value(a)
+	}
+	r.Attrs(value)
+	b.WriteString("\n")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// WithAttrs returns a copy of the receiver whose records include the given
+// attributes attrs.
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &c
+}
+
+// WithGroup returns the receiver; attribute groups are not distinguished in
+// text output.
+func (h *textHandler) WithGroup(string) slog.Handler {
+	return h
+}
@@ -0,0 +1,24 @@
+package main
+
+// makeTarget represents a phony target of a generated Makefile.
+type makeTarget struct {
+	name   string
+	help   string
+	recipe []string
+}
+
+// makefile returns the Makefile defining each of the given targets, in order.
+func makefile(targets ...makeTarget) Template {
+	tmpl := Template{}
+	for _, t := range targets {
+		tmpl = append(tmpl,
+			`# `+t.help,
+			`.PHONY: `+t.name,
+			t.name+`:`)
+		for _, r := range t.recipe {
+			tmpl = append(tmpl, "\t"+r)
+		}
+		tmpl = append(tmpl, ``)
+	}
+	return tmpl
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ardnew/mkgo/exitcode"
+	"github.com/ardnew/version"
+)
+
+func init() {
+	registerCommand(&command{
+		name:   "man",
+		usage:  "print roff man page",
+		hidden: true,
+		run:    runMan,
+	})
+}
+
+// manDefault maps the name of each command-line flag, whose default value is
+// derived from the user's environment, to a description of that default. These
+// are written to the man page instead of the values found on the host that
+// generated it.
+var manDefault = map[string]string{
+	"config": "$XDG_CONFIG_HOME/mkgo/config.yaml",
+	"d":      "the current date",
+	"u":      "the value of $USER",
+}
+
+// runMan writes the man page mkgo.1 to stdout.
+func runMan(arg []string) exitcode.Code {
+	if len(arg) != 0 {
+		logger.Error("unexpected arguments (use -h for help)")
+		return exitcode.Usage
+	}
+	manPage(os.Stdout)
+	return exitcode.OK
+}
+
+// roff escapes the given text s for use in a roff document.
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, `-`, `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// manPage writes the man page, generated from the definitions of all flags and
+// subcommands, to w.
+func manPage(w io.Writer) {
+	date := ""
+	if n := len(version.ChangeLog); n > 0 {
+		if t := version.ParseDate(version.ChangeLog[n-1].Date); t != nil {
+			date = t.Format("2006-01-02")
+		}
+	}
+	fmt.Fprintf(w, ".TH MKGO 1 %q %q \"User Commands\"\n", date, "mkgo "+moduleVersion())
+	fmt.Fprintf(w, ".SH NAME\n")
+	fmt.Fprintf(w, "mkgo \\- create a Go main module using template source file\n")
+	fmt.Fprintf(w, ".SH SYNOPSIS\n")
+	fmt.Fprintf(w, ".B mkgo\n[\\fIoptions\\fR] \\fIimport\\-path\\fR\n.br\n")
+	fmt.Fprintf(w, ".B mkgo\n\\fIcommand\\fR [\\fIarguments\\fR]\n")
+	fmt.Fprintf(w, ".SH DESCRIPTION\n")
+	fmt.Fprintf(w, "%s\n", roff("mkgo creates a new Go main module using a source code "+
+		"template. It integrates github.com/ardnew/version to embed a version and "+
+		"changelog, and it uses the standard flag package to accept command-line "+
+		"arguments."))
+	fmt.Fprintf(w, ".PP\n%s\n", roff("By default, the module at the given Go "+
+		"import path is created in the current directory, or as a package of "+
+		"the module enclosing the current directory if the import path is "+
+		"within that module. It is created relative to the first path found in "+
+		"the user's GOPATH environment variable instead when run from within "+
+		"GOPATH or with GO111MODULE=off. See -mode."))
+	fmt.Fprintf(w, ".SH OPTIONS\n")
+	flag.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(w, ".TP\n\\fB\\-%s\\fR", roff(f.Name))
+		if !isBoolFlag(f) {
+			name, _ := flag.UnquoteUsage(f)
+			if name == "" {
+				name = "value"
+			}
+			fmt.Fprintf(w, " \\fI%s\\fR", roff(name))
+		}
+		fmt.Fprintf(w, "\n%s", roff(f.Usage))
+		if def, ok := manDefault[f.Name]; ok {
+			fmt.Fprintf(w, " (default: %s)", roff(def))
+		} else if !isBoolFlag(f) && f.DefValue != "" {
+			fmt.Fprintf(w, " (default: %s)", roff(f.DefValue))
+		}
+		fmt.Fprintf(w, "\n")
+	})
+	fmt.Fprintf(w, ".SH COMMANDS\n")
+	for _, n := range commandNames() {
+		cmd := commands[n]
+		fmt.Fprintf(w, ".TP\n\\fB%s\\fR", roff(n))
+		if cmd.args != "" {
+			fmt.Fprintf(w, " \\fI%s\\fR", roff(cmd.args))
+		}
+		fmt.Fprintf(w, "\n%s\n", roff(cmd.usage))
+	}
+	fmt.Fprintf(w, ".SH EXIT STATUS\n")
+	for _, e := range exitcode.Table {
+		fmt.Fprintf(w, ".TP\n.B %d\n%s\n", e.Code, roff(e.Description))
+	}
+	fmt.Fprintf(w, ".SH ENVIRONMENT\n")
+	fmt.Fprintf(w, ".TP\n.B GOPATH\n%s\n", roff("The module is created "+
+		"relative to the src directory of the first path in this list in "+
+		"GOPATH mode."))
+	fmt.Fprintf(w, ".TP\n.B GO111MODULE\n%s\n", roff("If off, the module is "+
+		"created in GOPATH mode without go.mod."))
+	fmt.Fprintf(w, ".TP\n.B USER\n%s\n", roff("Default user name of the "+
+		"author, who also holds the copyright unless -org is given."))
+	fmt.Fprintf(w, ".SH SEE ALSO\n")
+	fmt.Fprintf(w, ".BR go (1),\n.BR goimports (1)\n")
+}
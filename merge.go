@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+)
+
+// Conflict markers written around each region where the current content of a
+// file and its new render disagree.
+const (
+	conflictBegin = "<<<<<<< current"
+	conflictSep   = "======="
+	conflictEnd   = ">>>>>>> mkgo"
+)
+
+// mergeConflicts returns the given current content of a file merged, line by
+// line, with its given new rendered content. Lines common to both are written
+// once, and each region where they disagree is written with git-style conflict
+// markers surrounding both versions, as found by diffLines; contents too large
+// to compare are written as a single conflict. Also returns whether or not any
+// conflicts were written.
+func mergeConflicts(current, rendered string) (string, bool) {
+	out, ours, theirs := []string{}, []string{}, []string{}
+	conflict := false
+	flush := func() {
+		if len(ours) > 0 || len(theirs) > 0 {
+			out = append(out, conflictBegin)
+			out = append(append(out, ours...), conflictSep)
+			out = append(append(out, theirs...), conflictEnd)
+			ours, theirs, conflict = ours[:0], theirs[:0], true
+		}
+	}
+	for _, l := range diffLines(strings.Split(current, "\n"), strings.Split(rendered, "\n")) {
+		switch l.kind {
+		case ' ':
+			flush()
+			out = append(out, l.text)
+		case '-':
+			ours = append(ours, l.text)
+		default:
+			theirs = append(theirs, l.text)
+		}
+	}
+	flush()
+	return strings.Join(out, "\n"), conflict
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string // the kind of each line
+	}{
+		{"a b c", "a b c", "   "},
+		{"a b c", "a x c", " -+ "},
+		{"a b", "a b c", "  +"},
+		{"a b c", "a c", " - "},
+		{"", "a", "-+"},
+	}
+	for _, tt := range tests {
+		kind := []byte{}
+		for _, l := range diffLines(strings.Split(tt.a, " "), strings.Split(tt.b, " ")) {
+			kind = append(kind, l.kind)
+		}
+		if string(kind) != tt.want {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", tt.a, tt.b, kind, tt.want)
+		}
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	region := func(ours, theirs string) string {
+		return strings.Join([]string{conflictBegin, ours, conflictSep, theirs, conflictEnd}, "\n")
+	}
+	tests := []struct {
+		name              string
+		current, rendered string
+		want              string
+		conflict          bool
+	}{
+		{"clean", "a\nb\nc\n", "a\nb\nc\n", "a\nb\nc\n", false},
+		{"one-sided", "a\nb\nc\n", "a\nb\nc\nd\n", "a\nb\nc\n" + conflictBegin + "\n" + conflictSep + "\nd\n" + conflictEnd + "\n", true},
+		{"conflicting", "a\nb\nc\n", "a\nx\nc\n", "a\n" + region("b", "x") + "\nc\n", true},
+	}
+	for _, tt := range tests {
+		got, conflict := mergeConflicts(tt.current, tt.rendered)
+		if got != tt.want || conflict != tt.conflict {
+			t.Errorf("%s: mergeConflicts() = %q, %t, want %q, %t", tt.name, got, conflict, tt.want, tt.conflict)
+		}
+	}
+}
@@ -1,20 +1,29 @@
 // mkgo creates a new Go main module using a source code template.
 // It integrates `github.com/ardnew/version` to embed a version and changelog,
 // and it uses the standard `flag` package to accept command-line arguments.
-// The module is created at a given Go import path relative to the first path
-// found in the user's `GOPATH` environment variable.
+// By default, the module at a given Go import path is created in the current
+// directory, or as a package of the module enclosing the current directory if
+// the import path is within that module. It is created relative to the first
+// path found in the user's `GOPATH` environment variable instead when run from
+// within `GOPATH` or with `GO111MODULE=off`. Use `-mode gopath` or
+// `-mode module` to force either behavior.
 package main
 
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"go/build"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ardnew/mkgo/exitcode"
+	"github.com/ardnew/mkgo/scaffold"
 	"github.com/ardnew/version"
 )
 
@@ -48,119 +57,499 @@ func main() {
 	var (
 		argChanges   bool
 		argVersion   bool
-		argMkDate    string
-		argMkVersion string
-		argReadme    bool
-		argLicense   string
-		argUser      string
-		argOverwrite bool
+		argLogLevel  string
+		argLogFormat string
+		argConfig    string
+		argJSON      bool
 	)
 
-	currDate := time.Now().Format(dateFormat)
-	currUser := os.Getenv("USER")
-
-	knownLicense := []string{}
-	for name := range licenseTemplate {
-		knownLicense = append(knownLicense, name)
-	}
+	// report invalid arguments with the documented exit status instead of the
+	// flag package's default.
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.Usage = usage
 
 	flag.BoolVar(&argChanges, "changelog", false, "display change history")
 	flag.BoolVar(&argVersion, "version", false, "display version information")
-	flag.StringVar(&argMkDate, "d", currDate, "date of initial revision")
-	flag.StringVar(&argMkVersion, "s", semVersion, "semantic version of initial revision")
-	flag.BoolVar(&argOverwrite, "f", false, "force overwriting file if it already exists")
-	flag.BoolVar(&argReadme, "r", false, "create a simple README.md")
-	flag.StringVar(&argLicense, "l", "", "create a LICENSE file (options: "+strings.Join(knownLicense, " ")+")")
-	flag.StringVar(&argUser, "u", currUser, "user name for license file copyright")
-	flag.Parse()
+	opt := newOptions(flag.CommandLine)
+	flag.StringVar(&argLogLevel, "log-level", "info", "minimum severity of log messages (options: "+strings.Join(logLevel, " ")+")")
+	flag.StringVar(&argConfig, "config", configPath(), "configuration file")
+	flag.BoolVar(&argJSON, "json", false, "write the summary of actions taken as JSON")
+	flag.StringVar(&argLogFormat, "log-format", "text", "format of log messages (options: "+strings.Join(logFormat, " ")+")")
+	if err := flag.CommandLine.Parse(os.Args[1:]); nil != err {
+		if err == flag.ErrHelp {
+			os.Exit(int(exitcode.OK))
+		}
+		os.Exit(int(exitcode.Usage))
+	}
+
+	if err := configureLogger(argLogLevel, argLogFormat); nil != err {
+		logger.Error(err.Error())
+		os.Exit(int(exitcode.Usage))
+	}
+
+	if cfg, err := loadConfig(argConfig); nil != err {
+		logger.Error("cannot read configuration file", "error", err)
+		os.Exit(int(exitcode.Config))
+	} else {
+		config = cfg
+	}
+	switch {
+	case !opt.isSet("open"):
+		opt.open = config.Open
+	case opt.open == "":
+		// -open= opens the module in the user's preferred editor.
+		opt.open = "true"
+	}
+
+	// subcommands are recognized only as the first non-flag argument, and they
+	// parse their own arguments.
+	if cmd, ok := commands[flag.Arg(0)]; ok {
+		os.Exit(int(cmd.run(flag.Args()[1:])))
+	}
 
 	if argChanges {
 		version.PrintChangeLog()
 	} else if argVersion {
-		fmt.Printf("mkgo version %s\n", version.String())
+		fmt.Printf("mkgo version %s\n", buildVersion())
 	} else {
 
 		if len(flag.Args()) == 0 {
-			fmt.Println("error: no package path specified (use -h for help)")
-			os.Exit(1)
+			logger.Error("no package path specified (use -h for help)")
+			os.Exit(int(exitcode.Usage))
 		}
 
-		path, name := packagePath(flag.Arg(0))
-		if err := os.MkdirAll(path, os.ModePerm); nil != err {
-			fmt.Printf("error: %s\n", err.Error())
-			os.Exit(2)
+		plan, code := newPlan(flag.Arg(0), opt)
+		if code != exitcode.OK {
+			os.Exit(int(code))
 		}
+		code = plan.applySummary(argJSON)
+		// the conflicts of a merged module are resolved in the editor.
+		if open := editorFor(opt.open); (code == exitcode.OK || code == exitcode.Conflict) && open != nil {
+			plan.openProject(open)
+		}
+		os.Exit(int(code))
+	}
+}
 
-		sourcePath := filepath.Join(path, name+".go")
-		if exists, isDir := fileExists(sourcePath); !exists || argOverwrite {
-			if isDir {
-				fmt.Printf("error: output file is a directory: %s\n", sourcePath)
-				os.Exit(3)
-			}
-			template.insert(flag.Arg(0), name, argMkDate, argMkVersion, argUser)
-			if err := ioutil.WriteFile(sourcePath, []byte(template.String()), 0664); nil != err {
-				fmt.Printf("error: %s\n", err.Error())
-				os.Exit(4)
-			}
-			if out, err := execCmd(path, "goimports", "-w", name+".go"); nil != err {
-				fmt.Print(out)
-				os.Exit(5)
-			}
-			if out, err := execCmd(path, "go", "mod", "init"); nil != err {
-				fmt.Print(out)
-				os.Exit(6)
-			}
-		} else {
-			fmt.Printf("error: file exists (use -f to overwrite): %s\n", sourcePath)
-			os.Exit(7)
+// options contains the command-line flags that determine the content of a
+// generated project.
+type options struct {
+	date       string
+	dateFormat string
+	years      string
+	version    string
+	readme     bool
+	noBadges   bool
+	noChanges  bool
+	license    string
+	header     bool
+	holderLine bool
+	notice     bool
+	user       string
+	email      string
+	org        string
+	copyright  string
+	authors    stringList
+	overwrite  bool
+	merge      bool
+	here       bool
+	mode       string
+	edit       bool
+	open       string
+	format     string
+	vscode     bool
+	precommit  bool
+	deps       string
+	toolchain  bool
+	envrc      bool
+	coverage   string
+	citation   bool
+	templates  string
+	cmds       cmdList
+	lib        bool
+	appType    string
+	broker     string
+	gateway    bool
+	debugHTTP  bool
+	metrics    bool
+	vars       varMap
+	debugTmpl  bool
+	strict     bool
+
+	insecure    bool
+	noHooks     bool
+	hooks       bool
+	templateSum string
+	templateSig string
+	templateKey string
+
+	flags *flag.FlagSet // defines each of the options above
+}
+
+// newOptions returns the options whose values are parsed from the command-line
+// flags it defines in the given flag set fs.
+func newOptions(fs *flag.FlagSet) *options {
+	opt := &options{flags: fs}
+	fs.StringVar(&opt.date, "d", time.Now().Format(dateFormat), "date of initial revision")
+	fs.StringVar(&opt.dateFormat, "date-format", "", "Go time layout or preset name of dates (presets: "+strings.Join(datePresetNames(), " ")+")")
+	fs.StringVar(&opt.years, "copyright-years", "", "`years` of copyright notices, a year or range such as 2018-2025 (default: year of -d)")
+	fs.StringVar(&opt.version, "s", semVersion, "semantic version of initial revision")
+	fs.BoolVar(&opt.overwrite, "f", false, "force overwriting file if it already exists")
+	fs.StringVar(&opt.mode, "mode", "auto", "where to create the module and whether to create go.mod (options: "+strings.Join(modeNames(), " ")+")")
+	fs.BoolVar(&opt.edit, "e", false, "edit description, changelog, and keywords in $EDITOR")
+	fs.StringVar(&opt.open, "open", "", "open the module in `editor` once created (-open= for $EDITOR)")
+	fs.BoolVar(&opt.here, "here", false, "allow creating the module in an existing non-empty repository")
+	fs.BoolVar(&opt.merge, "merge", false, "merge existing files, writing conflict markers where they differ")
+	fs.BoolVar(&opt.readme, "r", false, "create a simple README.md")
+	fs.BoolVar(&opt.noBadges, "no-badges", false, "omit the badges from README.md")
+	fs.BoolVar(&opt.noChanges, "no-changelog", false, "omit the change history from main packages and README.md")
+	fs.StringVar(&opt.license, "l", "", "create a LICENSE file (options: "+strings.Join(licenseNames(), " ")+", any SPDX license identifier, file:path of a custom license, or ? to choose; default: none)")
+	fs.BoolVar(&opt.header, "spdx", false, "begin each Go source file with the SPDX identifier of the license given with -l")
+	fs.BoolVar(&opt.header, "license-header", false, "alias of -spdx")
+	fs.BoolVar(&opt.holderLine, "copyright-header", false, "follow the SPDX identifier of -spdx with a copyright notice")
+	fs.BoolVar(&opt.notice, "notice", false, "create a NOTICE file for the Apache License 2.0 given with -l")
+	fs.StringVar(&opt.user, "u", "", "user name of the author (default: git config user.name, or $USER)")
+	fs.StringVar(&opt.email, "email", "", "email address of the author (default: git config user.email)")
+	fs.StringVar(&opt.org, "org", "", "organization holding the copyright, if not the authors")
+	fs.StringVar(&opt.copyright, "copyright", "", "copyright policy (options: "+strings.Join(copyrightPolicyNames(), " ")+")")
+	fs.Var(&opt.authors, "author", "additional author, listed in AUTHORS (repeatable)")
+	fs.StringVar(&opt.format, "fmt", "goimports", "source formatter (options: "+strings.Join(formatterNames(), " ")+")")
+	fs.BoolVar(&opt.vscode, "vscode", false, "create VS Code workspace settings and launch configuration")
+	fs.BoolVar(&opt.citation, "citation", false, "create a CITATION.cff")
+	fs.StringVar(&opt.coverage, "coverage", "", "create coverage reporting configuration (options: "+strings.Join(coverageServiceNames(), " ")+")")
+	fs.StringVar(&opt.deps, "deps", "", "create dependency update bot configuration (options: "+strings.Join(depBotNames(), " ")+")")
+	fs.BoolVar(&opt.envrc, "envrc", false, "create a direnv .envrc")
+	fs.BoolVar(&opt.toolchain, "toolchain", false, "create .go-version and .tool-versions pinning the Go toolchain")
+	fs.BoolVar(&opt.precommit, "precommit", false, "create pre-commit framework and golangci-lint configuration")
+	fs.Var(&opt.vars, "var", "template variable given as `name=value` (repeatable)")
+	fs.Var(&opt.cmds, "cmd", "command with main package in cmd/, sharing package internal/version (repeatable)")
+	fs.BoolVar(&opt.lib, "lib", false, "create a library package, named after its directory, instead of a main package")
+	fs.StringVar(&opt.appType, "type", "flag", "type of main package, or of library package with -lib (options: "+strings.Join(appTypeNames(), " ")+")")
+	fs.StringVar(&opt.broker, "broker", "nats", "message broker of -type consumer (options: "+strings.Join(brokerNames(), " ")+")")
+	fs.BoolVar(&opt.gateway, "gateway", false, "also serve the gRPC services of -type grpc as a REST API with grpc-gateway")
+	fs.BoolVar(&opt.debugHTTP, "debug-endpoints", false, "serve pprof and expvar debug endpoints, on the address of its -debug-addr flag, from the server of -type")
+	fs.BoolVar(&opt.metrics, "metrics", false, "serve Prometheus metrics, with example counters and histograms, from the server of -type")
+	fs.StringVar(&opt.templates, "t", "", "template set, by name, directory, or module query, rendered into the module")
+	fs.StringVar(&opt.templateSum, "template-sum", "", "expected checksum (as in go.sum) of the template module given with -t")
+	fs.StringVar(&opt.templateSig, "template-sig", "", "file or URL of a minisign signature of the template module given with -t")
+	fs.StringVar(&opt.templateKey, "template-key", "", "minisign public key, or its file, verifying -template-sig")
+	fs.BoolVar(&opt.insecure, "insecure", false, "skip verifying template modules")
+	fs.BoolVar(&opt.hooks, "template-hooks", false, "run the hooks of the template module given with -t without asking")
+	fs.BoolVar(&opt.noHooks, "no-template-hooks", false, "skip the commands declared by the hooks of the template set given with -t")
+	fs.BoolVar(&opt.debugTmpl, "debug-templates", false, "print the variables, functions, and unresolved tokens of each rendered file")
+	fs.BoolVar(&opt.strict, "strict", false, "fail if a placeholder token is left unresolved in any rendered file")
+	return opt
+}
+
+// licenseNames returns the sorted names of all known license templates.
+func licenseNames() []string {
+	name := []string{}
+	for n := range licenseTemplate {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+	return name
+}
+
+// authorList returns the names of all authors named by the receiver's options:
+// the user given with -u, followed by each author given with -author, or in the
+// configuration file if -author is not given.
+func (opt *options) authorList() []string {
+	more := opt.authors
+	if len(more) == 0 {
+		more = config.Authors
+	}
+	list := []string{}
+	seen := map[string]bool{}
+	for _, a := range append([]string{opt.user}, more...) {
+		if a != "" && !seen[a] {
+			list = append(list, a)
+			seen[a] = true
 		}
+	}
+	return list
+}
 
-		licensePath := filepath.Join(path, "LICENSE")
-		if license, ok := licenseTemplate[argLicense]; !ok {
-			fmt.Printf("error: unsupported license (use -h to view options): %s\n", argLicense)
-			os.Exit(8)
-		} else {
-			if exists, isDir := fileExists(licensePath); !exists || argOverwrite {
-				if isDir {
-					fmt.Printf("error: output file is a directory: %s\n", licensePath)
-					os.Exit(9)
-				}
-				license.insert(flag.Arg(0), name, argMkDate, argMkVersion, argUser)
-				if err := ioutil.WriteFile(licensePath, []byte(license.String()), 0664); nil != err {
-					fmt.Printf("error: %s\n", err.Error())
-					os.Exit(10)
-				}
-			} else {
-				fmt.Printf("error: file exists (use -f to overwrite): %s\n", licensePath)
-				os.Exit(11)
-			}
+// holders returns the copyright holders of the module with the given name, as
+// named by the receiver's options and the copyright policy given with
+// -copyright or in the configuration file:
+//
+//	individual  every author
+//	employer    the organization given with -org or in the configuration file
+//	authors     "The <name> Authors", with every author listed in AUTHORS
+//
+// Without a policy, the organization holds the copyright if one is defined, or
+// else every author.
+func (opt *options) holders(name string) ([]string, error) {
+	org := opt.organization()
+	switch policy := opt.policy(); policy {
+	case "":
+		if org != "" {
+			return []string{org}, nil
+		}
+		return opt.authorList(), nil
+	case "individual":
+		return opt.authorList(), nil
+	case "employer":
+		if org == "" {
+			return nil, fmt.Errorf("copyright policy %q requires -org", policy)
 		}
+		return []string{org}, nil
+	case "authors":
+		return []string{"The " + name + " Authors"}, nil
+	default:
+		return nil, fmt.Errorf("invalid copyright policy (options: %s): %s",
+			strings.Join(copyrightPolicyNames(), " "), policy)
+	}
+}
 
-		readmePath := filepath.Join(path, "README.md")
-		if exists, isDir := fileExists(readmePath); !exists || argOverwrite {
-			if isDir {
-				fmt.Printf("error: output file is a directory: %s\n", readmePath)
-				os.Exit(9)
-			}
-			readme.insert(flag.Arg(0), name, argMkDate, argMkVersion, argUser)
-			if err := ioutil.WriteFile(readmePath, []byte(readme.String()), 0664); nil != err {
-				fmt.Printf("error: %s\n", err.Error())
-				os.Exit(10)
-			}
-		} else {
-			fmt.Printf("error: file exists (use -f to overwrite): %s\n", readmePath)
-			os.Exit(11)
+// organization returns the organization given with -org or, if not given, in
+// the configuration file.
+func (opt *options) organization() string {
+	if opt.org != "" {
+		return opt.org
+	}
+	return config.Org
+}
+
+// userName returns the user name of the author given with -u or, if not given,
+// the name configured for git (user.name), which is usually the legal name
+// suitable for copyright notices, or else the login name in $USER.
+func (opt *options) userName() string {
+	if opt.isSet("u") {
+		return opt.user
+	}
+	if name := gitConfig("", "user.name"); name != "" {
+		return name
+	}
+	return os.Getenv("USER")
+}
+
+// authorEmail returns the email address of the author given with -email or,
+// if not given, in the configuration file, or else the email address
+// configured for git (user.email).
+func (opt *options) authorEmail() string {
+	switch {
+	case opt.email != "":
+		return opt.email
+	case config.Email != "":
+		return config.Email
+	}
+	return gitConfig("", "user.email")
+}
+
+// gitConfig returns the value of the given git configuration key, as seen from
+// the given directory dir, or the current directory if empty, or the empty
+// string if it is not set or git is not installed.
+func gitConfig(dir, key string) string {
+	cmd := exec.Command("git", "config", "--get", key)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if nil != err {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// policy returns the copyright policy given with -copyright or, if not given,
+// in the configuration file.
+func (opt *options) policy() string {
+	if opt.copyright != "" {
+		return opt.copyright
+	}
+	return config.Copyright
+}
+
+// copyrightPolicyNames returns the sorted names of all copyright policies.
+func copyrightPolicyNames() []string {
+	return []string{"authors", "employer", "individual"}
+}
+
+// isSet returns whether or not the command-line flag with the given name was
+// given.
+func (opt *options) isSet(name string) bool {
+	set := false
+	opt.flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// lookup returns a scaffold.Lookup resolving the names referenced by template
+// conditions to either the given substitutions vars or, if not found, the
+// values of the command-line flags defining the receiver options opt.
+func (opt *options) lookup(vars map[string]string) scaffold.Lookup {
+	return func(name string) (string, bool) {
+		if v, ok := vars[name]; ok {
+			return v, true
 		}
+		if f := opt.flags.Lookup(name); f != nil {
+			return f.Value.String(), true
+		}
+		return "", false
+	}
+}
+
+// stringList is a flag.Value that accumulates the argument of each occurrence
+// of a repeatable command-line flag.
+type stringList []string
+
+// String returns the receiver's elements separated by comma.
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends the given flag argument s to the receiver.
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// cmdList is a flag.Value that accumulates the name of the command given as
+// argument of each occurrence of a repeatable command-line flag, each a single
+// element of a path.
+type cmdList []string
 
-		fmt.Printf("mkgo: successfully created %q: %s\n", flag.Arg(0), path)
+// String returns the receiver's elements separated by comma.
+func (l *cmdList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends the command named by the given flag argument s to the receiver.
+func (l *cmdList) Set(s string) error {
+	if s == "" || s == "." || s == ".." || strings.ContainsAny(s, `/\`) || !filepath.IsLocal(s) {
+		return fmt.Errorf("expected the name of a command, without path separators: %q", s)
+	}
+	*l = append(*l, s)
+	return nil
+}
+
+// varMap is a flag.Value that defines a variable for the argument, formatted
+// "name=value", of each occurrence of a repeatable command-line flag.
+type varMap map[string]string
+
+// String returns the receiver's variables formatted "name=value", sorted by
+// name and separated by comma.
+func (m *varMap) String() string {
+	def := []string{}
+	for k, v := range *m {
+		def = append(def, k+"="+v)
+	}
+	sort.Strings(def)
+	return strings.Join(def, ",")
+}
+
+// Set defines the variable in the given flag argument s, formatted
+// "name=value", in the receiver.
+func (m *varMap) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || !varName.MatchString(k) {
+		return fmt.Errorf("expected name=value, with a name of letters, digits, and underscores: %q", s)
+	}
+	if *m == nil {
+		*m = varMap{}
+	}
+	(*m)[k] = v
+	return nil
+}
+
+// varName matches the name of a template variable.
+var varName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// datePresetNames returns the sorted names of all date format presets.
+func datePresetNames() []string {
+	name := []string{}
+	for n := range datePreset {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+	return name
+}
+
+// formatDate returns the given date, in any format recognized by package
+// github.com/ardnew/version, reformatted using the given layout, which is
+// either a date format preset name or a Go time layout. The preset named by
+// the configuration file is used if layout is empty. If date is not
+// recognized, it is returned verbatim.
+func formatDate(date, layout string) string {
+	if layout == "" {
+		layout = config.DateFormat
+	}
+	if p, ok := datePreset[layout]; ok {
+		layout = p
+	}
+	if layout == "" {
+		layout = dateFormat
+	}
+	t := version.ParseDate(date)
+	if t == nil {
+		return date
+	}
+	s := t.Format(layout)
+	if version.ParseDate(s) == nil {
+		logger.Warn("date format is not recognized by github.com/ardnew/version", "date", s)
+	}
+	return s
+}
+
+// dateYear returns the year of the given date, in any format recognized by
+// package github.com/ardnew/version, or the current year if date is not
+// recognized.
+func dateYear(date string) string {
+	if t := version.ParseDate(date); t != nil {
+		return t.Format("2006")
+	}
+	return time.Now().Format("2006")
+}
+
+// copyrightYears matches the years of a copyright notice given with
+// -copyright-years: a year or a range of years, first-last.
+var copyrightYears = regexp.MustCompile(`^(\d{4})(?:-(\d{4}))?$`)
+
+// copyrightYear returns the years of copyright notices given with
+// -copyright-years, if any, or the year of the -d date otherwise, and whether
+// or not they are valid.
+func (opt *options) copyrightYear() (string, bool) {
+	if opt.years == "" {
+		return dateYear(opt.date), true
+	}
+	m := copyrightYears.FindStringSubmatch(opt.years)
+	return opt.years, m != nil && (m[2] == "" || m[1] < m[2])
+}
+
+// truth returns the value of a variable that is true, for both text/template
+// conditionals and manifest conditions, if the given b is true, or the empty
+// string (false) otherwise.
+func truth(b bool) string {
+	if b {
+		return "true"
 	}
+	return ""
+}
+
+// formatterNames returns the sorted names of all supported source formatters.
+func formatterNames() []string {
+	name := []string{}
+	for n := range formatter {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+	return name
 }
 
 // execCmd runs the given system command cmd with given arguments arg from the
 // given working directory dir, returning the combined stdout/stderr output.
 func execCmd(dir, cmd string, arg ...string) (string, error) {
+	return execCmdEnv(dir, nil, cmd, arg...)
+}
+
+// execCmdEnv runs the given system command cmd, as with execCmd, in the given
+// environment env, or that of mkgo if env is nil.
+func execCmdEnv(dir string, env []string, cmd string, arg ...string) (string, error) {
+	logger.Debug("running command", "command", strings.Join(append([]string{cmd}, arg...), " "), "dir", dir)
 	c := exec.Command(cmd, arg...)
 	c.Dir = dir
+	c.Env = env
 	o, err := c.CombinedOutput()
 	return string(o), err
 }
@@ -199,13 +588,25 @@ func splitPath(path string) []string {
 	return part
 }
 
+// gopathSrc returns the src directory of the first path found in the user's
+// GOPATH environment variable, or of the default GOPATH if it is undefined.
+func gopathSrc() string {
+	gopath := filepath.SplitList(os.Getenv("GOPATH"))
+	if len(gopath) == 0 {
+		gopath = filepath.SplitList(build.Default.GOPATH)
+	}
+	if len(gopath) == 0 {
+		return ""
+	}
+	return filepath.Join(gopath[0], "src")
+}
+
 // packagePath returns the absolute file path of given Go package's import path
 // relative to the first path found in the user's GOPATH environment variable.
 func packagePath(path string) (full, name string) {
-	gopath := filepath.SplitList(os.Getenv("GOPATH"))
 	part := splitPath(path)
-	if len(gopath) > 0 {
-		full = filepath.Join(gopath[0], "src", filepath.Join(part...))
+	if src := gopathSrc(); src != "" {
+		full = filepath.Join(src, filepath.Join(part...))
 	}
 	if len(part) > 0 {
 		name = part[len(part)-1]
@@ -213,26 +614,78 @@ func packagePath(path string) (full, name string) {
 	return full, name
 }
 
+// majorSuffix matches the major version suffix (e.g., "/v2") of a module path.
+var majorSuffix = regexp.MustCompile(`/v([2-9]|[1-9][0-9]+)$`)
+
+// splitMajor returns the given import path imp without its major version
+// suffix, and the major version of that suffix, or 0 if imp has none.
+func splitMajor(imp string) (repo string, major int) {
+	m := majorSuffix.FindStringSubmatch(imp)
+	if m == nil {
+		return imp, 0
+	}
+	major, _ = strconv.Atoi(m[1])
+	return strings.TrimSuffix(imp, m[0]), major
+}
+
 // Template represents a file whose elements are individual lines of the file.
 type Template []string
 
-// insert replaces all placeholder tokens in the receiver Template's elements
-// with the given replacement values, returning the resulting Template.
-func (tmpl *Template) insert(path, name, date, version, user string) *Template {
-	for i, s := range *tmpl {
-		(*tmpl)[i] =
-			strings.ReplaceAll(
-				strings.ReplaceAll(
-					strings.ReplaceAll(
-						strings.ReplaceAll(
-							strings.ReplaceAll(s,
-								"__IMPORT__", path),
-							"__NAME__", name),
-						"__DATE__", date),
-					"__VERSION__", version),
-				"__USER__", user)
-	}
-	return tmpl
+// insert executes the receiver Template as a text/template with the given
+// vars as data, replacing its elements with the result. For compatibility, each
+// key of vars also names a placeholder token without its surrounding
+// underscores, e.g., the value of key "NAME" replaces token "__NAME__". The
+// given trace, if not nil, records the variables and functions used.
+func (tmpl *Template) insert(vars map[string]string, trace *scaffold.Trace) error {
+	s, err := scaffold.Execute("", tmpl.String(), vars, trace)
+	if nil != err {
+		return err
+	}
+	*tmpl = strings.Split(s, "\n")
+	return nil
+}
+
+// badge represents an image link displayed at the top of README.md.
+type badge struct {
+	name string // prefix of the Markdown reference link labels
+	alt  string
+	img  string
+	url  string
+}
+
+// readmeTemplate returns the given README.md template readme with the given
+// badges linked below its title.
+func readmeTemplate(readme Template, badges []badge) Template {
+	ref, link := Template{}, []string{}
+	for _, b := range badges {
+		ref = append(ref,
+			"["+b.name+"img]:"+b.img,
+			"["+b.name+"url]:"+b.url)
+		link = append(link, "[!["+b.alt+"]["+b.name+"img]]["+b.name+"url]")
+	}
+	// the badges are rendered only if WithReadmeBadges is true.
+	body := Template{`{{if .WithReadmeBadges}}` + strings.Join(append(ref, ``), "\n") + "\n{{end}}" + readme[0]}
+	body = append(body, readme[1])
+	body = append(body, readme[2]+`{{if .WithReadmeBadges}}`+"\n"+strings.Join(link, " ")+"\n{{end}}")
+	return append(body, readme[3:]...)
+}
+
+// expand returns a copy of the receiver Template in which every element that
+// contains the given placeholder token is repeated once for each of the given
+// values, with the token replaced by that value. The values are escaped so
+// that insert leaves them unchanged.
+func (tmpl Template) expand(token string, values []string) Template {
+	out := Template{}
+	for _, s := range tmpl {
+		if !strings.Contains(s, token) {
+			out = append(out, s)
+			continue
+		}
+		for _, v := range values {
+			out = append(out, strings.ReplaceAll(s, token, scaffold.Escape(v)))
+		}
+	}
+	return out
 }
 
 // String returns all elements of the receiver Template joined by newline.
@@ -240,110 +693,37 @@ func (tmpl *Template) String() string {
 	return strings.Join(*tmpl, "\n")
 }
 
+// file returns the content of the file of the receiver Template: its elements
+// joined by newline, ending in a newline unless empty, since the final newline
+// of a built-in Template is not part of it.
+func (tmpl *Template) file() string {
+	s := tmpl.String()
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	return s
+}
+
 var (
 	dateFormat = "2006 Jan 02"
+	datePreset = map[string]string{
+		"default": dateFormat,
+		"iso8601": "2006-01-02",
+		"rfc3339": time.RFC3339,
+		"rfc1123": time.RFC1123,
+	}
 	semVersion = "0.1.0"
-	template   = Template{
-		`package main`,
-		``,
-		`import (`,
-		`	"flag"`,
-		`	"fmt"`,
-		``,
-		`	"github.com/ardnew/version"`,
-		`)`,
-		``,
-		`func init() {`,
-		`	version.ChangeLog = []version.Change{{`,
-		`		Package: "__NAME__",`,
-		`		Version: "__VERSION__",`,
-		`		Date:    "__DATE__",`,
-		`		Description: []string{`,
-		`			"initial implementation",`,
-		`		},`,
-		`	}}`,
-		`}`,
-		``,
-		`func main() {`,
-		``,
-		`	var (`,
-		`		argVersion bool`,
-		`		argChanges bool`,
-		`	)`,
-		``,
-		`	flag.BoolVar(&argVersion, "v", false, "Display version information")`,
-		`	flag.BoolVar(&argChanges, "V", false, "Display change history")`,
-		`	flag.Parse()`,
-		``,
-		`	if argChanges {`,
-		`		version.PrintChangeLog()`,
-		`	} else if argVersion {`,
-		`		fmt.Printf("__NAME__ version %s\n", version.String())`,
-		`	} else {`,
-		`		// main`,
-		`	}`,
-		`}`,
-	}
-	licenseTemplate = map[string]Template{
-		"MIT": Template{
-			`MIT License`,
-			``,
-			`Copyright (c) 2020 __USER__`,
-			``,
-			`Permission is hereby granted, free of charge, to any person obtaining a copy`,
-			`of this software and associated documentation files (the "Software"), to deal`,
-			`in the Software without restriction, including without limitation the rights`,
-			`to use, copy, modify, merge, publish, distribute, sublicense, and/or sell`,
-			`copies of the Software, and to permit persons to whom the Software is`,
-			`furnished to do so, subject to the following conditions:`,
-			``,
-			`The above copyright notice and this permission notice shall be included in all`,
-			`copies or substantial portions of the Software.`,
-			``,
-			`THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR`,
-			`IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,`,
-			`FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE`,
-			`AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER`,
-			`LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,`,
-			`OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE`,
-			`SOFTWARE.			`,
-		},
+	formatter  = map[string][]string{
+		"goimports": {"goimports", "-w"},
+		"gofmt":     {"gofmt", "-w"},
+		"gofumpt":   {"gofumpt", "-w"},
 	}
-	readme = Template{
-		`[docimg]:https://godoc.org/__IMPORT__?status.svg`,
-		`[docurl]:https://godoc.org/__IMPORT__`,
-		`[repimg]:https://goreportcard.com/badge/__IMPORT__`,
-		`[repurl]:https://goreportcard.com/report/__IMPORT__`,
-		``,
-		`# __NAME__`,
-		`#### __NAME__`,
-		``,
-		`[![GoDoc][docimg]][docurl] [![Go Report Card][repimg]][repurl]`,
-		``,
-		`## Usage`,
-		``,
-		`How to use:`,
-		``,
-		"```sh",
-		`__NAME__ ...`,
-		"```",
-		``,
-		"Use the `-h` flag for usage summary:",
-		``,
-		"```",
-		`Usage of __NAME__:`,
-		`  -changelog`,
-		`		display change history`,
-		`  -version`,
-		`		display version information`,
-		"```",
-		``,
-		`## Installation`,
-		``,
-		`Use the builtin Go package manager:`,
-		``,
-		"```sh",
-		`go get -v __IMPORT__`,
-		"```",
+	authors        = builtin.must("AUTHORS")
+	template       = builtin.must("main.go")
+	readme         = builtin.must("README.md")
+	noticeTemplate = builtin.must("NOTICE")
+	readmeBadge    = []badge{
+		{"doc", "GoDoc", "https://godoc.org/__IMPORT__?status.svg", "https://godoc.org/__IMPORT__"},
+		{"rep", "Go Report Card", "https://goreportcard.com/badge/__REPO__", "https://goreportcard.com/report/__REPO__"},
 	}
 )
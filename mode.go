@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// modeNames returns the sorted names of all supported modes, which select
+// where a module is created and whether or not it is initialized with go.mod.
+func modeNames() []string {
+	return []string{"auto", "gopath", "module"}
+}
+
+// destination returns the directory in which the module with the given import
+// path imp, without its major version suffix repo, is created using the given
+// mode, and whether or not it is initialized with "go mod init".
+//
+// In auto mode, GOPATH mode is used if GO111MODULE is off. Otherwise, a module
+// is created relative to GOPATH if the current directory is in GOPATH, as a
+// package of the enclosing module if imp is a path in that module, or else in
+// the current directory.
+func destination(imp, repo, mode string) (dir string, modInit bool, err error) {
+	gopath, _ := packagePath(repo)
+	name := filepath.Base(filepath.FromSlash(repo))
+	cwd, err := os.Getwd()
+	if nil != err {
+		return "", false, err
+	}
+	switch mode {
+	case "gopath":
+		return gopath, false, nil
+	case "module":
+		return filepath.Join(cwd, name), true, nil
+	case "auto":
+	default:
+		return "", false, fmt.Errorf("invalid mode (options: %s): %s",
+			strings.Join(modeNames(), " "), mode)
+	}
+
+	out, _ := execCmd("", "go", "env", "GO111MODULE", "GOMOD")
+	env := strings.Split(out, "\n")
+	if strings.TrimSpace(env[0]) == "off" {
+		return gopath, false, nil
+	}
+	if src := gopathSrc(); src != "" && isWithin(cwd, src) {
+		return gopath, true, nil
+	}
+	if len(env) > 1 {
+		if gomod := strings.TrimSpace(env[1]); gomod != "" && gomod != os.DevNull {
+			root := filepath.Dir(gomod)
+			if mod := modulePathOf(gomod); mod != "" && strings.HasPrefix(imp, mod+"/") {
+				return filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(imp, mod+"/"))), false, nil
+			}
+		}
+	}
+	return filepath.Join(cwd, name), true, nil
+}
+
+// isWithin returns whether or not the given path is the given directory dir or
+// any of its descendants.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return nil == err && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// modulePathOf returns the module path declared in the go.mod file at the
+// given path, or the empty string if it cannot be read.
+func modulePathOf(gomod string) string {
+	b, err := os.ReadFile(gomod)
+	if nil != err {
+		return ""
+	}
+	return modfile.ModulePath(b)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// projectEditors contains the names of editors that open a whole project
+// directory instead of a single file.
+var projectEditors = map[string]bool{
+	"code": true, "codium": true, "cursor": true, "zed": true, "subl": true,
+	"atom": true, "idea": true, "goland": true,
+}
+
+// editorFor returns the command line of the editor named by the given value of
+// the -open flag, or nil if the module is not opened. The value "true" names
+// the user's preferred editor.
+func editorFor(open string) []string {
+	switch open {
+	case "", "false":
+		return nil
+	case "true":
+		return editorCommand()
+	}
+	return strings.Fields(open)
+}
+
+// openProject opens the module created by the receiver Plan p in the editor
+// of the given command line cmd. Editors of whole projects are given the
+// module directory, and all others its first source file. Any failure is
+// logged as a warning, since the module was created successfully.
+func (p *Plan) openProject(cmd []string) {
+	target := p.Dir
+	if !projectEditors[filepath.Base(cmd[0])] {
+		for _, f := range p.Files {
+			if f.Role == "source" {
+				target = filepath.Join(p.Dir, f.Path)
+				break
+			}
+		}
+	}
+	c := exec.Command(cmd[0], append(cmd[1:], target)...)
+	c.Dir = p.Dir
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	logger.Debug("opening project", "command", strings.Join(c.Args, " "))
+	if err := c.Run(); nil != err {
+		logger.Warn("cannot open project", "command", cmd[0], "error", err)
+	}
+}
@@ -0,0 +1,1055 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ardnew/mkgo/exitcode"
+	"github.com/ardnew/mkgo/scaffold"
+	"golang.org/x/mod/semver"
+)
+
+func init() {
+	registerCommand(&command{
+		name:  "plan",
+		args:  "[-out file] [options] import-path",
+		usage: "record the actions that would create a module, without executing them",
+		run:   runPlan,
+	})
+	registerCommand(&command{
+		name:  "apply",
+		args:  "[-json] plan-file",
+		usage: "execute the actions recorded in a plan",
+		run:   runApply,
+	})
+}
+
+// Plan describes every action taken to create a module: the substitutions used
+// to render its files, each file written, and each command run afterward. A
+// Plan is constructed without modifying the file system, so that it may be
+// saved, reviewed, and then applied.
+//
+// A Plan also records the state of the environment it assumes: which files
+// already exist and the tools used to run each command. A saved Plan is only
+// applied if that state has not changed.
+type Plan struct {
+	Import    string            `json:"import"`
+	Dir       string            `json:"dir"`
+	Overwrite bool              `json:"overwrite"`
+	Vars      map[string]string `json:"vars"`
+	Files     []PlanFile        `json:"files"`
+	Dirs      []string          `json:"dirs,omitempty"` // relative to Dir, created even if empty
+	Commands  []PlanCommand     `json:"commands"`
+	// PreCommands are run before any file is written.
+	PreCommands []PlanCommand     `json:"pre-commands,omitempty"`
+	Hooks       Hooks             `json:"hooks"`
+	Tools       map[string]string `json:"tools"`
+}
+
+// PlanFile describes a file written by a Plan.
+type PlanFile struct {
+	Path    string   `json:"path"` // relative to the Plan's Dir
+	Role    string   `json:"role"` // either "source" or "doc"
+	Mode    fileMode `json:"mode"`
+	Exists  bool     `json:"exists"`        // whether or not it will be overwritten
+	Sum     string   `json:"sum,omitempty"` // SHA-256 of the file it overwrites
+	Content string   `json:"content"`
+	// Conflict is true if Content has merge conflict markers.
+	Conflict bool `json:"conflict,omitempty"`
+	// Encoding is "base64" if Content is the base64 encoding of binary data.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// PlanCommand describes a command run by a Plan from the Plan's Dir.
+type PlanCommand struct {
+	Args []string      `json:"args"`
+	Exit exitcode.Code `json:"exit"` // exit status of mkgo if the command fails
+	// Env is true if the substitutions are exported to it, as to hooks.
+	Env bool `json:"env,omitempty"`
+}
+
+// bytes returns the content of the receiver PlanFile f, decoded according to
+// its Encoding.
+func (f *PlanFile) bytes() ([]byte, error) {
+	switch f.Encoding {
+	case "":
+		return []byte(f.Content), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(f.Content)
+	}
+	return nil, fmt.Errorf("unknown encoding: %s", f.Encoding)
+}
+
+// fileMode is an os.FileMode encoded as an octal string.
+type fileMode os.FileMode
+
+// MarshalText returns the receiver's permission bits as an octal string.
+func (m fileMode) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%04o", uint32(m))), nil
+}
+
+// UnmarshalText parses the receiver's permission bits from an octal string.
+func (m *fileMode) UnmarshalText(b []byte) error {
+	u, err := strconv.ParseUint(string(b), 8, 32)
+	if nil != err {
+		return err
+	}
+	*m = fileMode(u)
+	return nil
+}
+
+// fileSpec describes a file of a module whose content is rendered from a
+// Template when a Plan is constructed, or copied verbatim from raw if non-nil.
+type fileSpec struct {
+	path string
+	role string
+	mode fileMode
+	tmpl Template
+	raw  []byte
+}
+
+// overrideSpec returns the given files spec with the given file f appended, or
+// replacing the file in spec with the same path, if any.
+func overrideSpec(spec []fileSpec, f fileSpec) []fileSpec {
+	for i := range spec {
+		if spec[i].path == f.path {
+			spec[i] = f
+			return spec
+		}
+	}
+	return append(spec, f)
+}
+
+// appendSpec returns the given files spec with the given file f appended,
+// unless spec already contains a file with the same path.
+func appendSpec(spec []fileSpec, f fileSpec) []fileSpec {
+	for i := range spec {
+		if spec[i].path == f.path {
+			return spec
+		}
+	}
+	return append(spec, f)
+}
+
+// printTrace writes the given trace of rendering the file at the given path to
+// w.
+func printTrace(w io.Writer, path string, trace *scaffold.Trace) {
+	list := func(s []string) string {
+		if len(s) == 0 {
+			return "-"
+		}
+		return strings.Join(s, " ")
+	}
+	fmt.Fprintf(w, "template: %s\n", path)
+	fmt.Fprintf(w, "  vars:       %s\n", list(trace.Vars))
+	fmt.Fprintf(w, "  funcs:      %s\n", list(trace.Funcs))
+	fmt.Fprintf(w, "  unresolved: %s\n", list(trace.Unresolved))
+}
+
+// renderPath returns the file path of the given slash-separated path p of a
+// file or directory of a template set, rendered with the given vars, relative
+// to the module. Any failure, including a path outside of the module, is
+// logged, and the exit status of mkgo is returned.
+func renderPath(p string, vars map[string]string) (string, exitcode.Code) {
+	name, err := scaffold.Execute(p, p, vars, nil)
+	if nil != err {
+		logger.Error("cannot render template path", "path", p, "error", err)
+		return "", exitcode.Template
+	}
+	name = filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		logger.Error("template file is outside of module", "path", p)
+		return "", exitcode.Template
+	}
+	return name, exitcode.OK
+}
+
+// fileCode contains the exit status of mkgo for each failure related to a file
+// with a given role.
+var fileCode = map[string]struct{ isDir, write, exists exitcode.Code }{
+	"source": {exitcode.SourceIsDir, exitcode.SourceWrite, exitcode.SourceExists},
+	"doc":    {exitcode.DocIsDir, exitcode.DocWrite, exitcode.DocExists},
+}
+
+// newPlan returns the Plan that creates a module with the given import path imp,
+// using the given options opt. Any failure is logged, and the exit status of
+// mkgo is returned with a nil Plan.
+func newPlan(imp string, opt *options) (*Plan, exitcode.Code) {
+	opt.user = opt.userName()
+	// files of a module with a major version suffix (e.g., "/v2") are placed in
+	// the repository root, following the major branch convention.
+	repo, major := splitMajor(imp)
+	_, name := packagePath(repo)
+	dir, modInit, err := destination(imp, repo, opt.mode)
+	if nil != err {
+		logger.Error("cannot determine module directory", "error", err)
+		return nil, exitcode.Usage
+	}
+	if why := dangerous(dir, opt.here || opt.overwrite || opt.merge); why != "" {
+		if !confirm("create module in " + dir + " (" + why + ")") {
+			logger.Error("refusing to create module in dangerous destination", "path", dir, "reason", why)
+			return nil, exitcode.Unsafe
+		}
+	}
+	ver := opt.version
+	if major > 0 && semver.Compare(semverOf(ver), fmt.Sprintf("v%d.0.0", major)) < 0 {
+		if opt.isSet("s") {
+			logger.Warn("version does not match major version suffix", "version", ver, "import", imp)
+		} else {
+			ver = fmt.Sprintf("%d.0.0", major)
+		}
+	}
+	if opt.lib && len(opt.cmds) > 0 {
+		logger.Error("library package cannot have commands (use either -lib or -cmd)")
+		return nil, exitcode.Usage
+	}
+	app, ok := appTypes[opt.appType]
+	if !ok {
+		logger.Error("unsupported type of main package (use -h to view options)", "type", opt.appType)
+		return nil, exitcode.Usage
+	}
+	switch {
+	case app.broker != nil:
+		if app, ok = app.broker[opt.broker]; !ok {
+			logger.Error("unsupported message broker (use -h to view options)", "broker", opt.broker)
+			return nil, exitcode.Usage
+		}
+	case opt.isSet("broker"):
+		logger.Error("message broker requires a type of main package using one (use -type consumer)", "type", opt.appType)
+		return nil, exitcode.Usage
+	}
+	if opt.gateway {
+		if app.gateway == nil {
+			logger.Error("REST gateway requires a type of main package serving gRPC (use -type grpc)", "type", opt.appType)
+			return nil, exitcode.Usage
+		}
+		app = *app.gateway
+	}
+	if opt.debugHTTP && !app.server {
+		logger.Error("debug endpoints require a type of main package serving clients (use -type http, for example)", "type", opt.appType)
+		return nil, exitcode.Usage
+	}
+	if opt.metrics && app.metrics == nil {
+		logger.Error("metrics require a type of main package serving clients (use -type http, for example)", "type", opt.appType)
+		return nil, exitcode.Usage
+	}
+	switch {
+	case app.lib && !opt.lib:
+		logger.Error("type of library package requires -lib", "type", opt.appType)
+		return nil, exitcode.Usage
+	case app.file != nil && !app.lib && (opt.lib || len(opt.cmds) > 0):
+		logger.Error("type of main package cannot be combined with -lib or -cmd", "type", opt.appType)
+		return nil, exitcode.Usage
+	}
+	pkg, readmeBase := "main", readme
+	if opt.lib {
+		pkg, readmeBase = libPackage(name), libReadme
+	}
+	if app.readme != nil {
+		readmeBase = app.readme
+	}
+	date := formatDate(opt.date, opt.dateFormat)
+	author := opt.authorList()
+	// the user is listed in AUTHORS and CITATION.cff with their email address.
+	if email := opt.authorEmail(); email != "" && len(author) > 0 && author[0] == opt.user &&
+		!strings.Contains(opt.user, "<") {
+		author[0] += " <" + email + ">"
+	}
+	holder, err := opt.holders(name)
+	if nil != err {
+		logger.Error("cannot determine copyright holders", "error", err)
+		return nil, exitcode.Usage
+	}
+	year, ok := opt.copyrightYear()
+	if !ok {
+		logger.Error("invalid copyright years (use a year or range first-last)", "years", opt.years)
+		return nil, exitcode.Usage
+	}
+	fm := &form{changes: defaultChanges}
+	if opt.edit {
+		if fm, err = editForm(name); nil != err {
+			logger.Error("cannot edit description", "error", err)
+			return nil, exitcode.Editor
+		}
+	}
+	p := &Plan{
+		Import:    imp,
+		Dir:       dir,
+		Overwrite: opt.overwrite,
+		Hooks:     config.Hooks,
+		Vars: map[string]string{
+			"IMPORT":  imp,
+			"REPO":    repo,
+			"NAME":    name,
+			"PACKAGE": pkg,
+			"DATE":    date,
+			"YEAR":    year,
+			"VERSION": ver,
+			"USER":    opt.user,
+			"EMAIL":   opt.authorEmail(),
+			"ORG":     opt.organization(),
+			"HOLDER":  strings.Join(holder, ", "),
+
+			"DESCRIPTION": strings.Join(fm.description, " "),
+			"KEYWORDS":    strings.Join(fm.keywords, ", "),
+			"LICENSE":     opt.license,
+
+			"WithReadmeBadges":   truth(!opt.noBadges),
+			"WithChangelog":      truth(!opt.noChanges),
+			"WithLicenseHeader":  "",
+			"WithDebugEndpoints": truth(opt.debugHTTP),
+			"WithMetrics":        truth(opt.metrics),
+		},
+	}
+
+	for k, v := range opt.vars {
+		if _, ok := p.Vars[k]; ok {
+			logger.Error("cannot redefine variable provided by mkgo", "var", k)
+			return nil, exitcode.Usage
+		}
+		p.Vars[k] = v
+	}
+
+	if opt.license == licenseChooser {
+		id, ok := chooseLicense(p.Vars, holder)
+		if !ok {
+			logger.Error("no license chosen (use -l)")
+			return nil, exitcode.License
+		}
+		opt.license = id
+	}
+	// the license is optional: none is created without -l or with -l none.
+	licenseID := []string{}
+	if opt.license != "" && opt.license != "none" {
+		licenseID = strings.Split(opt.license, ",")
+	}
+	// each license given with -l, its SPDX identifier, and the file it is
+	// written to. The licenses of CITATION.cff must be in the SPDX license list.
+	licenseDoc, spdx, cite := []fileSpec{}, []string{}, []string{}
+	for _, id := range licenseID {
+		id = strings.TrimSpace(id)
+		license, ref, code := loadLicense(id)
+		if code != exitcode.OK {
+			return nil, code
+		}
+		if !strings.HasPrefix(id, licenseFilePrefix) {
+			cite = append(cite, id)
+		}
+		file := "LICENSE"
+		if f, ok := licensePath[id]; ok {
+			file = f
+		}
+		if len(licenseID) > 1 {
+			file = "LICENSE-" + licenseSuffix(ref, licenseDoc)
+		}
+		for _, r := range spdx {
+			if r == ref {
+				logger.Error("duplicate license", "license", id)
+				return nil, exitcode.License
+			}
+		}
+		spdx = append(spdx, ref)
+		licenseDoc = append(licenseDoc, fileSpec{file, "doc", 0664, license, nil})
+	}
+	p.Vars["LICENSE"] = strings.Join(spdx, " OR ")
+	p.Vars["WithLicenseHeader"] = truth(opt.header && len(spdx) > 0)
+	apache := false
+	for _, id := range spdx {
+		apache = apache || id == "Apache-2.0"
+	}
+	if opt.notice && !apache {
+		logger.Error("NOTICE file requires the Apache License 2.0 (use -l Apache-2.0)")
+		return nil, exitcode.Usage
+	}
+	notice := []string{}
+	if opt.header && len(licenseID) == 1 {
+		for _, line := range licenseNotice[opt.license] {
+			notice = append(notice, strings.TrimSpace("// "+line))
+		}
+		if len(notice) > 0 {
+			notice = append([]string{"//"}, notice...)
+		}
+	}
+	// the notices of the GNU licenses already begin with a copyright notice.
+	if opt.header && opt.holderLine && !strings.Contains(strings.Join(notice, "\n"), "__HOLDER__") {
+		notice = append([]string{"// Copyright (c) __YEAR__ __HOLDER__"}, notice...)
+	}
+	format, ok := formatter[opt.format]
+	if !ok {
+		logger.Error("unsupported formatter (use -h to view options)", "fmt", opt.format)
+		return nil, exitcode.Usage
+	}
+	bot, ok := depBot[depBotFor(opt.deps, imp)]
+	if !ok && opt.deps != "" {
+		logger.Error("unsupported dependency update bot (use -h to view options)", "deps", opt.deps)
+		return nil, exitcode.Usage
+	}
+	cover, ok := coverageService[opt.coverage]
+	if !ok && opt.coverage != "" {
+		logger.Error("unsupported coverage service (use -h to view options)", "coverage", opt.coverage)
+		return nil, exitcode.Usage
+	}
+	badges := readmeBadge
+	targets := []makeTarget{}
+	if opt.coverage != "" {
+		badges = append(append([]badge{}, badges...), cover.badge(imp))
+		targets = append(targets, coverageTarget)
+	}
+	github := strings.HasPrefix(imp, "github.com/")
+
+	gover := ""
+	if opt.toolchain {
+		if gover = goVersion(); gover == "" {
+			logger.Error("cannot determine Go toolchain version")
+			return nil, exitcode.Usage
+		}
+	}
+
+	var set *scaffold.Set
+	var require []string
+	if opt.templates != "" {
+		var code exitcode.Code
+		if set, code = loadTemplateSet(opt.templates); code != exitcode.OK {
+			return nil, code
+		}
+		if code = opt.verifyTemplate(); code != exitcode.OK {
+			return nil, code
+		}
+		if v := set.Manifest.MinVersion; v != "" && semver.Compare(semverOf(moduleVersion()), semverOf(v)) < 0 {
+			logger.Error("template set requires a newer mkgo (use self-update)",
+				"path", opt.templates, "version", v)
+			return nil, exitcode.Template
+		}
+		require = set.Manifest.Requires
+		// the script of the set runs only once every variable it may read has
+		// a valid value.
+		p.Vars = set.Defaults(p.Vars)
+		promptTemplateVars(set, p.Vars)
+		if miss := set.Missing(p.Vars); len(miss) > 0 {
+			logger.Error("missing required template variables (use -var to define)", "path", opt.templates,
+				"vars", strings.Join(miss, ", "))
+			return nil, exitcode.Template
+		}
+		var err error
+		if p.Vars, err = set.Check(p.Vars); nil != err {
+			logger.Error("invalid template variable", "path", opt.templates, "error", err)
+			return nil, exitcode.Usage
+		}
+		if p.Vars, err = set.Run(p.Vars, opt.lookup(p.Vars)); nil != err {
+			logger.Error("cannot run template script", "path", opt.templates, "error", err)
+			return nil, exitcode.Template
+		}
+	}
+	// a template set that is a Go module (e.g., downloaded with a module query)
+	// is rewritten to the new module path, and provides its own main package
+	// and go.mod, to which mkgo adds only its documents.
+	module := nil != set && scaffold.ModulePath(set.FS) != ""
+	if module {
+		fsys, err := scaffold.Rewrite(set.FS, imp, name)
+		if nil != err {
+			logger.Error("cannot rewrite template module", "template", opt.templates, "error", err)
+			return nil, exitcode.Template
+		}
+		set.FS, modInit = fsys, false
+	}
+
+	spec, source, launch, env := []fileSpec{}, []string{name + ".go"}, vscodeLaunch, []string{}
+	if module && len(opt.cmds) == 0 {
+		source = []string{}
+	}
+	if len(opt.cmds) > 0 {
+		spec, source = cmdFiles(opt.cmds), []string{versionPath}
+		for _, c := range opt.cmds {
+			source = append(source, cmdPath(c))
+		}
+		launch = cmdLaunch(vscodeLaunch, opt.cmds[0])
+	}
+	switch {
+	case len(opt.cmds) > 0 || module:
+	case opt.lib && app.file == nil:
+		spec, source = libFiles(pkg), []string{}
+		for _, f := range spec {
+			source = append(source, f.path)
+		}
+	case app.file != nil:
+		source = []string{}
+		for _, f := range app.file {
+			rendered, code := renderPath(f.path, p.Vars)
+			if code != exitcode.OK {
+				return nil, code
+			}
+			f.path = rendered
+			spec = append(spec, f)
+			if filepath.Ext(f.path) == ".go" {
+				source = append(source, f.path)
+			}
+		}
+		if opt.debugHTTP {
+			spec, source = append(spec, fileSpec{"debug.go", "source", 0664, debugTemplate, nil}), append(source, "debug.go")
+		}
+		if opt.metrics {
+			spec, source = append(spec, fileSpec{"metrics.go", "source", 0664, app.metrics, nil}), append(source, "metrics.go")
+		}
+		require = append(append([]string{}, require...), app.require...)
+		if opt.metrics {
+			require = append(require, metricsModule)
+		}
+		targets, env = append(targets, app.target...), app.env
+	default:
+		spec = append(spec, fileSpec{name + ".go", "source", 0664, template, nil})
+	}
+	spec = append(spec, licenseDoc...)
+	// the GNU Lesser General Public License supplements the GNU General Public
+	// License, which must be distributed along with it.
+	lesser := false
+	for _, id := range spdx {
+		lesser = lesser || id == "LGPL-3.0-or-later"
+	}
+	for _, id := range spdx {
+		lesser = lesser && id != "GPL-3.0-or-later"
+	}
+	for _, f := range []struct {
+		path string
+		role string
+		tmpl Template
+		when bool
+	}{
+		{"COPYING", "doc", licenseTemplate["GPL-3.0-or-later"], lesser},
+		{"NOTICE", "doc", noticeTemplate, opt.notice},
+		{"README.md", "doc", append(readmeTemplate(readmeBase, badges), readmeLicense(spdx, licenseDoc)...), opt.readme},
+		{"AUTHORS", "doc", authors, len(author) > 1 || len(author) > 0 && opt.policy() == "authors"},
+		{"CITATION.cff", "doc", citation(author, date, cite, fm.keywords), opt.citation},
+		{filepath.Join(".vscode", "settings.json"), "doc", vscodeSettings(opt.format), opt.vscode},
+		{filepath.Join(".vscode", "launch.json"), "doc", launch, opt.vscode},
+		{".pre-commit-config.yaml", "doc", precommitConfig(opt.format), opt.precommit},
+		{".golangci.yml", "doc", golangciConfig(opt.format), opt.precommit},
+		{bot.path, "doc", bot.tmpl, opt.deps != ""},
+		{".go-version", "doc", goVersionFile(gover), opt.toolchain},
+		{".tool-versions", "doc", toolVersionsFile(gover), opt.toolchain},
+		{".envrc", "doc", envrc(env...), opt.envrc},
+		{cover.path, "doc", cover.tmpl, cover.path != ""},
+		{coverageWorkflowPath, "doc", coverageWorkflow(cover.upload), opt.coverage != "" && github},
+		{"Makefile", "doc", makefile(targets...), len(targets) > 0},
+		{lockPath, "doc", lockFile(downloaded[opt.templates]), downloaded[opt.templates] != nil},
+	} {
+		if f.when {
+			spec = append(spec, fileSpec{f.path, f.role, 0664, f.tmpl, nil})
+		}
+	}
+	if nil != set {
+		file, err := set.Files(opt.lookup(p.Vars))
+		if nil != err {
+			logger.Error("cannot read template set", "path", opt.templates, "error", err)
+			return nil, exitcode.Template
+		}
+		for _, f := range file {
+			name, code := renderPath(f.Path, p.Vars)
+			if code != exitcode.OK {
+				return nil, code
+			}
+			role := "doc"
+			if path.Ext(name) == ".go" {
+				role = "source"
+			}
+			sp := fileSpec{path: name, role: role, mode: fileMode(f.Mode)}
+			if f.Binary {
+				sp.raw = f.Content
+			} else {
+				text := string(f.Content)
+				if !f.Action {
+					text = scaffold.Escape(text)
+				}
+				sp.tmpl = strings.Split(text, "\n")
+			}
+			if module {
+				spec = appendSpec(spec, sp)
+			} else {
+				spec = overrideSpec(spec, sp)
+			}
+		}
+		dirs, err := set.Dirs(opt.lookup(p.Vars))
+		if nil != err {
+			logger.Error("cannot read template set", "path", opt.templates, "error", err)
+			return nil, exitcode.Template
+		}
+		for _, d := range dirs {
+			name, code := renderPath(d, p.Vars)
+			if code != exitcode.OK {
+				return nil, code
+			}
+			if exists, isDir := fileExists(filepath.Join(dir, name)); exists && !isDir {
+				logger.Error("output directory is a file", "path", filepath.Join(dir, name))
+				return nil, exitcode.CreateDir
+			}
+			p.Dirs = append(p.Dirs, name)
+		}
+	}
+
+	spec, code := runPlugins(p, spec)
+	if code != exitcode.OK {
+		return nil, code
+	}
+
+	// a plugin may have replaced the list of copyright holders with a single
+	// holder, which is then used for every copyright notice.
+	if h := p.Vars["HOLDER"]; h != strings.Join(holder, ", ") {
+		holder = []string{h}
+	}
+	// a library package is documented even without a description.
+	doc := fm.docLines()
+	if opt.lib && len(doc) == 0 {
+		doc = []string{"// Package " + pkg + " implements " + name + "."}
+	}
+	conflicted := map[string]bool{} // files with conflict markers are not formatted
+	for _, f := range spec {
+		full := filepath.Join(dir, f.path)
+		exists, isDir := fileExists(full)
+		if isDir {
+			logger.Error("output file is a directory", "path", full)
+			return nil, fileCode[f.role].isDir
+		}
+		if exists && !opt.overwrite && (!opt.merge || f.raw != nil) {
+			logger.Error("file exists (use -f to overwrite or -merge to merge)", "path", full)
+			return nil, fileCode[f.role].exists
+		}
+		pf := PlanFile{
+			Path: f.path, Role: f.role, Mode: f.mode, Exists: exists, Sum: fileSum(full),
+		}
+		if f.raw != nil {
+			pf.Content, pf.Encoding = base64.StdEncoding.EncodeToString(f.raw), "base64"
+		} else {
+			body := f.tmpl.expand("__NOTICE__", notice).
+				expand("__AUTHOR__", author).expand("__HOLDER__", holder).
+				expand("__DOC__", doc).expand("__CHANGE__", fm.changeLines()).
+				expand("__DESCRIPTION__", fm.readmeLines())
+			var trace *scaffold.Trace
+			if opt.debugTmpl || opt.strict {
+				trace = &scaffold.Trace{}
+			}
+			if err := body.insert(p.Vars, trace); nil != err {
+				logger.Error("cannot render template", "path", f.path, "error", err)
+				return nil, exitcode.Template
+			}
+			if opt.debugTmpl {
+				printTrace(os.Stderr, f.path, trace)
+			}
+			if tok := trace.Placeholders(); opt.strict && len(tok) > 0 {
+				logger.Error("unresolved placeholder tokens (use -var to define)", "path", f.path,
+					"tokens", strings.Join(tok, ", "))
+				return nil, exitcode.Template
+			}
+			pf.Content = body.file()
+			if exists && opt.merge {
+				cur, err := os.ReadFile(full)
+				if nil != err {
+					logger.Error("cannot read file", "path", full, "error", err)
+					return nil, fileCode[f.role].write
+				}
+				var conflict bool
+				if pf.Content, conflict = mergeConflicts(string(cur), pf.Content); conflict {
+					logger.Warn("merge conflicts (resolve them in an editor)", "path", full)
+					conflicted[f.path] = true
+					pf.Conflict = true
+				}
+			}
+		}
+		p.Files = append(p.Files, pf)
+	}
+
+	args := append([]string{}, format...)
+	for _, src := range source {
+		if !conflicted[src] {
+			args = append(args, src)
+		}
+	}
+	if len(args) > len(format) {
+		p.Commands = append(p.Commands, PlanCommand{Args: args, Exit: exitcode.Format})
+	}
+	// a regenerated module keeps its existing go.mod.
+	if exists, _ := fileExists(filepath.Join(dir, "go.mod")); modInit && (!exists || !opt.merge) {
+		p.Commands = append(p.Commands,
+			PlanCommand{Args: []string{"go", "mod", "init", imp}, Exit: exitcode.ModInit})
+	}
+	// modules pinned to a version are written to go.mod directly; the others
+	// are resolved to their latest version.
+	for _, r := range require {
+		mod, ver, _ := strings.Cut(r, "@")
+		if ver == "" || ver == "latest" {
+			p.Commands = append(p.Commands, PlanCommand{
+				Args: []string{"go", "get", mod + "@latest"}, Exit: exitcode.Require,
+			})
+		} else {
+			p.Commands = append(p.Commands, PlanCommand{
+				Args: []string{"go", "mod", "edit", "-require=" + mod + "@" + ver}, Exit: exitcode.Require,
+			})
+		}
+	}
+	if app.generate && !module {
+		p.Commands = append(p.Commands, PlanCommand{
+			Args: []string{"go", "generate", "./..."}, Exit: exitcode.Generate,
+		})
+	}
+	if len(require) > 0 {
+		p.Commands = append(p.Commands, PlanCommand{
+			Args: []string{"go", "mod", "tidy"}, Exit: exitcode.Require,
+		})
+	}
+	if nil != set && !opt.noHooks {
+		var pre, post []PlanCommand
+		for _, h := range []struct {
+			hook []string
+			cmd  *[]PlanCommand
+		}{
+			{set.Manifest.Hooks.Pre, &pre},
+			{set.Manifest.Hooks.Post, &post},
+		} {
+			for _, line := range h.hook {
+				args, err := scaffold.HookArgs(line)
+				if nil != err {
+					logger.Error("invalid template hook", "command", line, "error", err)
+					return nil, exitcode.Template
+				}
+				for i := range args {
+					if args[i], err = scaffold.Execute(line, args[i], p.Vars, nil); nil != err {
+						logger.Error("cannot render template hook", "command", line, "error", err)
+						return nil, exitcode.Template
+					}
+				}
+				*h.cmd = append(*h.cmd, PlanCommand{Args: args, Exit: exitcode.Hook, Env: true})
+			}
+		}
+		if trustHooks(opt, set, append(append([]PlanCommand{}, pre...), post...)) {
+			p.PreCommands = append(p.PreCommands, pre...)
+			p.Commands = append(p.Commands, post...)
+		}
+	}
+	p.Tools = map[string]string{}
+	for _, c := range append(append([]PlanCommand{}, p.PreCommands...), p.Commands...) {
+		p.Tools[c.Args[0]] = toolVersion(c.Args[0])
+	}
+	if len(p.Hooks.Pre)+len(p.Hooks.Post) > 0 {
+		sh := hookShell()[0]
+		p.Tools[sh] = toolVersion(sh)
+	}
+	// every plan is checked as if read from a file, so that no option can
+	// write outside of the module directory.
+	if !p.valid() {
+		logger.Error("invalid plan", "import", imp)
+		return nil, exitcode.Plan
+	}
+	return p, exitcode.OK
+}
+
+// trustHooks returns whether or not the given commands, declared by the hooks
+// of the given template set set, given with -t, may be run. Those of a remote
+// set, i.e., one downloaded from a module proxy or extending one, are run only
+// with -template-hooks or if the user, shown each command, approves them;
+// otherwise they are skipped with a warning.
+func trustHooks(opt *options, set *scaffold.Set, hook []PlanCommand) bool {
+	if len(hook) == 0 || !set.Remote || opt.hooks {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "mkgo: the template set %s, downloaded or extending a downloaded one, declares hooks:\n", opt.templates)
+	for _, c := range hook {
+		fmt.Fprintf(os.Stderr, "\t%s\n", strings.Join(c.Args, " "))
+	}
+	if confirm("run these commands") {
+		return true
+	}
+	logger.Warn("skipping the hooks of the downloaded template set (use -template-hooks to run them)",
+		"template", opt.templates)
+	return false
+}
+
+// fileSum returns the hex-encoded SHA-256 checksum of the content of the file at
+// the given path, or the empty string if it cannot be read.
+func fileSum(path string) string {
+	b, err := os.ReadFile(path)
+	if nil != err {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// toolVersion identifies the executable that runs the given command cmd: the Go
+// toolchain version for "go", and the path of the executable found in PATH for
+// any other command, or the empty string if none is found.
+func toolVersion(cmd string) string {
+	exe, err := exec.LookPath(cmd)
+	if nil != err {
+		return ""
+	}
+	if cmd == "go" {
+		if out, err := execCmd("", exe, "env", "GOVERSION"); nil == err {
+			return strings.TrimSpace(out) + " " + exe
+		}
+	}
+	return exe
+}
+
+// valid returns whether or not the receiver Plan, e.g., one read from a file,
+// is safe to apply: its files and directories must not leave its module
+// directory, and each of its commands, as well as the shell of its hooks, must
+// have a recorded tool. Each problem found is logged.
+func (p *Plan) valid() bool {
+	ok := true
+	for _, f := range p.Files {
+		if !filepath.IsLocal(f.Path) {
+			logger.Error("output file outside of module directory", "path", f.Path)
+			ok = false
+		}
+	}
+	for _, d := range p.Dirs {
+		if !filepath.IsLocal(d) {
+			logger.Error("output directory outside of module directory", "path", d)
+			ok = false
+		}
+	}
+	for _, c := range append(append([]PlanCommand{}, p.PreCommands...), p.Commands...) {
+		if len(c.Args) == 0 {
+			logger.Error("empty command in plan")
+			ok = false
+		} else if _, found := p.Tools[c.Args[0]]; !found {
+			logger.Error("command has no tool recorded in plan", "command", c.Args[0])
+			ok = false
+		}
+	}
+	if sh := hookShell()[0]; len(p.Hooks.Pre)+len(p.Hooks.Post) > 0 {
+		if _, found := p.Tools[sh]; !found {
+			logger.Error("hooks have no shell recorded in plan", "command", sh)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// verify returns whether or not the current environment still matches the
+// environment assumed when the receiver Plan was constructed. Each difference
+// found is logged.
+func (p *Plan) verify() bool {
+	ok := true
+	for _, f := range p.Files {
+		full := filepath.Join(p.Dir, f.Path)
+		exists, isDir := fileExists(full)
+		switch {
+		case isDir:
+			logger.Error("output file is a directory", "path", full)
+			ok = false
+		case exists && !f.Exists:
+			logger.Error("file created since plan", "path", full)
+			ok = false
+		case exists && fileSum(full) != f.Sum:
+			logger.Error("file modified since plan", "path", full)
+			ok = false
+		}
+	}
+	for cmd, want := range p.Tools {
+		if have := toolVersion(cmd); have != want {
+			logger.Error("tool changed since plan", "command", cmd,
+				"planned", want, "found", have)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// apply executes each action of the receiver Plan, in order, stopping at the
+// first failure. Any failure is logged, and the exit status of mkgo is
+// returned, which is exitcode.Conflict if every action succeeded but a file
+// was written with merge conflicts.
+func (p *Plan) apply(sum *Summary) exitcode.Code {
+	logger.Debug("creating directory", "path", p.Dir)
+	if err := os.MkdirAll(p.Dir, os.ModePerm); nil != err {
+		logger.Error("cannot create directory", "error", err)
+		return exitcode.CreateDir
+	}
+	if code := runHooks(p.Dir, p.Vars, p.Hooks.Pre, sum); code != exitcode.OK {
+		return code
+	}
+	if code := p.run(p.PreCommands, sum); code != exitcode.OK {
+		return code
+	}
+	for _, f := range p.Files {
+		full := filepath.Join(p.Dir, f.Path)
+		content, err := f.bytes()
+		if nil != err {
+			logger.Error("cannot decode file", "path", full, "error", err)
+			return exitcode.Plan
+		}
+		// an existing file with identical content and executable bits is left
+		// alone.
+		if f.Exists && f.Sum == fmt.Sprintf("%x", sha256.Sum256(content)) {
+			if info, err := os.Stat(full); nil == err && info.Mode()&0111 == os.FileMode(f.Mode)&0111 {
+				logger.Debug("skipping unchanged file", "path", full)
+				sum.addFile(f.Path, "skipped", len(content))
+				continue
+			}
+		}
+		logger.Debug("writing file", "path", full)
+		if err := os.MkdirAll(filepath.Dir(full), os.ModePerm); nil != err {
+			logger.Error("cannot create directory", "error", err)
+			return exitcode.CreateDir
+		}
+		if err := os.WriteFile(full, content, os.FileMode(f.Mode)); nil != err {
+			logger.Error("cannot write file", "error", err)
+			return fileCode[f.Role].write
+		}
+		action := "created"
+		// the mode of an overwritten file is not changed by os.WriteFile.
+		if f.Exists {
+			if err := os.Chmod(full, os.FileMode(f.Mode)); nil != err {
+				logger.Error("cannot change file mode", "error", err)
+				return fileCode[f.Role].write
+			}
+			action = "overwritten"
+		}
+		sum.addFile(f.Path, action, len(content))
+	}
+	for _, d := range p.Dirs {
+		full := filepath.Join(p.Dir, d)
+		if _, isDir := fileExists(full); isDir {
+			continue
+		}
+		logger.Debug("creating directory", "path", full)
+		if err := os.MkdirAll(full, os.ModePerm); nil != err {
+			logger.Error("cannot create directory", "error", err)
+			return exitcode.CreateDir
+		}
+		sum.addFile(d+string(filepath.Separator), "created", 0)
+	}
+	if code := p.run(p.Commands, sum); code != exitcode.OK {
+		return code
+	}
+	if code := runHooks(p.Dir, p.Vars, p.Hooks.Post, sum); code != exitcode.OK {
+		return code
+	}
+
+	if err := addRecent(recentProject{
+		Import: p.Import, Path: p.Dir, Created: time.Now(),
+	}); nil != err {
+		logger.Warn("cannot update recent projects", "error", err)
+	}
+	code := exitcode.OK
+	for _, f := range p.Files {
+		if f.Conflict {
+			logger.Error("merge conflicts remain (resolve them in an editor)", "path", filepath.Join(p.Dir, f.Path))
+			code = exitcode.Conflict
+		}
+	}
+	return code
+}
+
+// run runs each of the given commands cmd of the receiver Plan p, in order,
+// from its directory, recording each in the given summary, and stopping at the
+// first failure. Any failure is logged, and the exit status of mkgo is
+// returned.
+func (p *Plan) run(cmd []PlanCommand, sum *Summary) exitcode.Code {
+	for _, c := range cmd {
+		var env []string
+		if c.Env {
+			env = hookEnv(p.Vars)
+		}
+		start := time.Now()
+		out, err := execCmdEnv(p.Dir, env, c.Args[0], c.Args[1:]...)
+		sum.addCommand(c.Args, start, nil != err)
+		if nil != err {
+			logger.Error("command failed", "command", c.Args[0], "error", err, "output", out)
+			return c.Exit
+		}
+	}
+	return exitcode.OK
+}
+
+// applySummary applies the receiver Plan p and then writes a summary of the
+// actions taken to stdout, either as text or, if asJSON is true, as JSON.
+func (p *Plan) applySummary(asJSON bool) exitcode.Code {
+	sum := &Summary{Import: p.Import, Dir: p.Dir}
+	code := p.apply(sum)
+	sum.Exit = int(code)
+	if err := sum.write(os.Stdout, asJSON); nil != err {
+		logger.Warn("cannot write summary", "error", err)
+	}
+	return code
+}
+
+// runApply verifies and then executes the Plan read from the file given as
+// argument.
+func runApply(arg []string) exitcode.Code {
+	var argJSON bool
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	fs.BoolVar(&argJSON, "json", false, "write the summary of actions taken as JSON")
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if fs.NArg() != 1 {
+		logger.Error("expected one plan file (use -h for help)")
+		return exitcode.Usage
+	}
+	b, err := os.ReadFile(fs.Arg(0))
+	if nil != err {
+		logger.Error("cannot read plan", "error", err)
+		return exitcode.Plan
+	}
+	p := &Plan{}
+	if err := json.Unmarshal(b, p); nil != err {
+		logger.Error("cannot decode plan", "path", fs.Arg(0), "error", err)
+		return exitcode.Plan
+	}
+	if !p.valid() {
+		logger.Error("invalid plan", "path", fs.Arg(0))
+		return exitcode.Plan
+	}
+	if !p.verify() {
+		logger.Error("environment does not match plan (create a new plan)", "path", fs.Arg(0))
+		return exitcode.Stale
+	}
+	return p.applySummary(argJSON)
+}
+
+// runPlan writes the Plan that would create the module with the import path
+// given as argument, in JSON format, to stdout or the file given with -out.
+func runPlan(arg []string) exitcode.Code {
+	var argOut string
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	fs.StringVar(&argOut, "out", "", "write plan to file instead of stdout")
+	opt := newOptions(fs)
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if fs.NArg() == 0 {
+		logger.Error("no package path specified (use -h for help)")
+		return exitcode.Usage
+	}
+
+	p, code := newPlan(fs.Arg(0), opt)
+	if code != exitcode.OK {
+		return code
+	}
+	b, err := json.MarshalIndent(p, "", "\t")
+	if nil != err {
+		logger.Error("cannot encode plan", "error", err)
+		return exitcode.Plan
+	}
+	b = append(b, '\n')
+	if argOut == "" {
+		os.Stdout.Write(b)
+		return exitcode.OK
+	}
+	if err := os.WriteFile(argOut, b, 0664); nil != err {
+		logger.Error("cannot write plan", "error", err)
+		return exitcode.Plan
+	}
+	logger.Info("plan written", "path", argOut)
+	return exitcode.OK
+}
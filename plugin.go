@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ardnew/mkgo/exitcode"
+	"github.com/ardnew/mkgo/scaffold"
+)
+
+// pluginPrefix is the prefix of the name of every plugin executable.
+const pluginPrefix = "mkgo-plugin-"
+
+// pluginRequest is the render context written, in JSON format, to the standard
+// input of each plugin.
+type pluginRequest struct {
+	Import string            `json:"import"`
+	Dir    string            `json:"dir"`
+	Vars   map[string]string `json:"vars"`
+	Files  []string          `json:"files"`
+}
+
+// pluginResponse is read, in JSON format, from the standard output of each
+// plugin. Each of its Vars is added to, or replaces, the substitutions used to
+// render every file, and each of its Files is rendered using those
+// substitutions and added to the module. A plugin with no output changes
+// nothing.
+type pluginResponse struct {
+	Vars  map[string]string `json:"vars"`
+	Files []struct {
+		Path    string   `json:"path"`
+		Mode    fileMode `json:"mode"`
+		Content string   `json:"content"`
+	} `json:"files"`
+}
+
+// runPlugins runs each plugin listed in the configuration file, in order, with
+// the render context of the given Plan p and files spec. The substitutions of
+// p are updated, and the given files spec are returned with those added by the
+// plugins appended, or replacing the files with the same path. Any failure is logged, and the exit status of mkgo is
+// returned.
+func runPlugins(p *Plan, spec []fileSpec) ([]fileSpec, exitcode.Code) {
+	for _, name := range config.Plugins {
+		req := pluginRequest{Import: p.Import, Dir: p.Dir, Vars: p.Vars}
+		for _, f := range spec {
+			req.Files = append(req.Files, filepath.ToSlash(f.path))
+		}
+		rsp, err := runPlugin(name, &req)
+		if nil != err {
+			logger.Error("plugin failed", "plugin", name, "error", err)
+			return nil, exitcode.Plugin
+		}
+		for k, v := range rsp.Vars {
+			p.Vars[k] = v
+		}
+		for _, f := range rsp.Files {
+			path := filepath.Clean(filepath.FromSlash(f.Path))
+			if !filepath.IsLocal(path) {
+				logger.Error("plugin file is outside of module", "plugin", name, "path", f.Path)
+				return nil, exitcode.Plugin
+			}
+			mode := f.Mode
+			if mode == 0 {
+				mode = 0664
+			}
+			role := "doc"
+			if filepath.Ext(path) == ".go" {
+				role = "source"
+			}
+			// a file of a plugin replaces any file with the same path.
+			spec = overrideSpec(spec, fileSpec{path, role, mode, strings.Split(scaffold.Escape(f.Content), "\n"), nil})
+		}
+	}
+	return spec, exitcode.OK
+}
+
+// runPlugin runs the plugin with the given name, writing the given request req
+// to its standard input and returning the response read from its standard
+// output. The plugin's standard error is passed through to mkgo's.
+func runPlugin(name string, req *pluginRequest) (*pluginResponse, error) {
+	in, err := json.Marshal(req)
+	if nil != err {
+		return nil, err
+	}
+	var out bytes.Buffer
+	c := exec.Command(pluginPrefix + name)
+	c.Dir = req.Dir
+	if _, err := os.Stat(c.Dir); nil != err {
+		c.Dir = "" // the module directory is not created until applied
+	}
+	c.Stdin = bytes.NewReader(in)
+	c.Stdout = &out
+	c.Stderr = os.Stderr
+	logger.Debug("running plugin", "plugin", name)
+	if err := c.Run(); nil != err {
+		return nil, err
+	}
+	rsp := &pluginResponse{}
+	if b := bytes.TrimSpace(out.Bytes()); len(b) > 0 {
+		if err := json.Unmarshal(b, rsp); nil != err {
+			return nil, err
+		}
+	}
+	return rsp, nil
+}
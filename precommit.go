@@ -0,0 +1,54 @@
+package main
+
+import "strings"
+
+// precommitConfig returns the pre-commit framework configuration whose hooks
+// format Go source files with the given source formatter, run go vet, verify
+// go.mod and go.sum are tidy, and run golangci-lint with the configuration
+// returned by golangciConfig.
+func precommitConfig(format string) Template {
+	return Template{
+		`# See https://pre-commit.com for more information`,
+		`repos:`,
+		`  - repo: local`,
+		`    hooks:`,
+		`      - id: go-fmt`,
+		`        name: ` + format,
+		`        entry: ` + strings.Join(formatter[format], " "),
+		`        language: system`,
+		`        types: [go]`,
+		`      - id: go-vet`,
+		`        name: go vet`,
+		`        entry: go vet ./...`,
+		`        language: system`,
+		`        types: [go]`,
+		`        pass_filenames: false`,
+		`      - id: go-mod-tidy`,
+		`        name: go mod tidy`,
+		`        entry: go mod tidy -diff`,
+		`        language: system`,
+		`        files: '(\.go|go\.mod|go\.sum)$'`,
+		`        pass_filenames: false`,
+		`      - id: golangci-lint`,
+		`        name: golangci-lint`,
+		`        entry: golangci-lint run --config .golangci.yml`,
+		`        language: system`,
+		`        types: [go]`,
+		`        pass_filenames: false`,
+	}
+}
+
+// golangciConfig returns the golangci-lint configuration that enables the
+// standard linters and checks formatting with the given source formatter.
+func golangciConfig(format string) Template {
+	return Template{
+		`version: "2"`,
+		``,
+		`linters:`,
+		`  default: standard`,
+		``,
+		`formatters:`,
+		`  enable:`,
+		`    - ` + format,
+	}
+}
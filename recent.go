@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// recentLimit is the maximum number of recently created projects remembered.
+const recentLimit = 50
+
+// recentProject describes a project created by mkgo.
+type recentProject struct {
+	Import  string    `json:"import"`
+	Path    string    `json:"path"`
+	Created time.Time `json:"created"`
+}
+
+// recentPath returns the path of the cache file listing recently created
+// projects, recent.json in the mkgo subdirectory of the user's cache directory.
+func recentPath() string {
+	dir, err := os.UserCacheDir()
+	if nil != err {
+		return ""
+	}
+	return filepath.Join(dir, "mkgo", "recent.json")
+}
+
+// loadRecent returns the recently created projects, most recent first. Any
+// error reading the cache is treated as an empty cache.
+func loadRecent() []recentProject {
+	recent := []recentProject{}
+	if path := recentPath(); path != "" {
+		if b, err := os.ReadFile(path); nil == err {
+			_ = json.Unmarshal(b, &recent)
+		}
+	}
+	return recent
+}
+
+// addRecent records the given project p as the most recently created project.
+func addRecent(p recentProject) error {
+	path := recentPath()
+	if path == "" {
+		return nil
+	}
+	recent := []recentProject{p}
+	for _, r := range loadRecent() {
+		if r.Import != p.Import && len(recent) < recentLimit {
+			recent = append(recent, r)
+		}
+	}
+	b, err := json.MarshalIndent(recent, "", "\t")
+	if nil != err {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); nil != err {
+		return err
+	}
+	return os.WriteFile(path, b, 0664)
+}
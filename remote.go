@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ardnew/mkgo/scaffold"
+)
+
+// isModuleQuery returns whether or not the given template set source is a
+// module query, i.e., a module path and version separated by "@" (e.g.,
+// "example.com/template@latest"), rather than a name or directory.
+func isModuleQuery(source string) bool {
+	mod, ver, ok := strings.Cut(source, "@")
+	return ok && mod != "" && ver != "" && !filepath.IsAbs(mod) &&
+		!strings.HasPrefix(mod, ".") && strings.Contains(mod, ".")
+}
+
+// moduleInfo is the information about a module printed by "go mod download".
+type moduleInfo struct {
+	Path    string
+	Version string // resolved from the query, e.g., a pseudo-version of a commit
+	Query   string
+	Dir     string
+	Zip     string // path of the module's zip file in the module cache
+	Sum     string // checksum of the module's files, as in go.sum
+	Error   string
+}
+
+// downloaded contains the information about each module downloaded by
+// downloadModule, keyed by the query resolving it.
+var downloaded = map[string]*moduleInfo{}
+
+// lockPath is the path, relative to a module, of the file recording the module
+// query, and the version it resolved, of the template set the module was
+// created from.
+const lockPath = ".mkgo.yaml"
+
+// lockFile returns the content of the file at lockPath recording the given
+// information about the module of a template set, or nil if info is nil.
+func lockFile(info *moduleInfo) Template {
+	if info == nil {
+		return nil
+	}
+	return Template{
+		"# Created by mkgo from the template module below. To create it again, run:",
+		"#   mkgo -t " + scaffold.Escape(info.Path+"@"+info.Version),
+		"template:",
+		"  query: " + scaffold.Escape(info.Query),
+		"  module: " + scaffold.Escape(info.Path),
+		"  version: " + scaffold.Escape(info.Version),
+		"  sum: " + scaffold.Escape(info.Sum),
+	}
+}
+
+// downloadModule downloads the module resolved by the given module query to
+// the module cache with "go mod download", through the Go module proxy, and
+// returns the information about it. The version of the query may be any
+// accepted by the go command: a semantic version, a branch or tag name, or a
+// commit hash.
+func downloadModule(query string) (*moduleInfo, error) {
+	if info, ok := downloaded[query]; ok {
+		return info, nil
+	}
+	logger.Debug("running command", "command", "go mod download -json "+query)
+	c := exec.Command("go", "mod", "download", "-json", query)
+	c.Dir = os.TempDir() // outside of any module
+	out, err := c.Output()
+	info := &moduleInfo{}
+	if jerr := json.Unmarshal(out, info); nil != jerr {
+		if nil != err {
+			var exit *exec.ExitError
+			if errors.As(err, &exit) {
+				return nil, fmt.Errorf("go mod download: %s", strings.TrimSpace(string(exit.Stderr)))
+			}
+			return nil, err
+		}
+		return nil, jerr
+	}
+	if info.Error != "" {
+		return nil, errors.New(info.Error)
+	}
+	if nil != err {
+		return nil, err
+	}
+	info.Query, downloaded[query] = query, info
+	return info, nil
+}
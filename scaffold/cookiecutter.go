@@ -0,0 +1,468 @@
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing/fstest"
+)
+
+// CookiecutterName is the name of the context file in the root of a
+// cookiecutter template (https://cookiecutter.readthedocs.io), which Load
+// converts to a template set when it has no manifest.
+const CookiecutterName = "cookiecutter.json"
+
+// cookiecutterAlias maps the names of variables commonly declared by
+// cookiecutter templates to the variable provided by mkgo used as their
+// default value.
+var cookiecutterAlias = map[string]string{
+	"project_name": "NAME",
+	"project_slug": "NAME",
+	"repo_name":    "NAME",
+	"app_name":     "NAME",
+	"package_name": "NAME",
+	"module_name":  "NAME",
+	"module_path":  "IMPORT",
+	"go_module":    "IMPORT",
+	"author":       "USER",
+	"author_name":  "USER",
+	"full_name":    "USER",
+	"version":      "VERSION",
+}
+
+// loadCookiecutter returns the template set converted from the cookiecutter
+// template in the given fsys, whose context file contains the given JSON data.
+//
+// The files rendered are those of the single directory in the root of fsys
+// whose name references a variable, e.g., "{{cookiecutter.project_slug}}",
+// which corresponds to the module. Each variable of the context is declared
+// with its default value (or the first of its choices, which are declared as
+// its only valid values unless they contain Jinja expressions), and the Jinja
+// expressions and statements of each file are converted to their text/template
+// equivalents, including those in their paths. Files matching the patterns of
+// "_copy_without_render" are copied verbatim.
+func loadCookiecutter(fsys fs.FS, data []byte) (*Set, error) {
+	s := &Set{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if t, err := dec.Token(); nil != err || t != json.Delim('{') {
+		return nil, &fs.PathError{Op: "parse", Path: CookiecutterName, Err: fmt.Errorf("expected object")}
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if nil != err {
+			return nil, &fs.PathError{Op: "parse", Path: CookiecutterName, Err: err}
+		}
+		name := t.(string)
+		var val interface{}
+		if err := dec.Decode(&val); nil != err {
+			return nil, &fs.PathError{Op: "parse", Path: CookiecutterName, Err: err}
+		}
+		if strings.HasPrefix(name, "_") {
+			if name == "_copy_without_render" {
+				for _, p := range toStrings(val) {
+					s.Manifest.Files = append(s.Manifest.Files, FileRule{Path: p, Binary: true})
+				}
+			}
+			continue
+		}
+		if !ident.MatchString(name) {
+			return nil, &fs.PathError{Op: "parse", Path: CookiecutterName,
+				Err: fmt.Errorf("invalid variable name: %s", name)}
+		}
+		def, choice := "", []string(nil)
+		switch v := val.(type) {
+		case string:
+			def = v
+		case json.Number, bool:
+			def = toString(v)
+		case []interface{}:
+			if len(v) > 0 {
+				def = toString(v[0])
+			}
+			choice = toStrings(v)
+		default:
+			continue // dicts are not supported
+		}
+		if alias, ok := cookiecutterAlias[name]; ok {
+			def = "{{." + alias + "}}"
+		} else if def, err = jinja(def); nil != err {
+			return nil, &fs.PathError{Op: "convert", Path: CookiecutterName + ":" + name, Err: err}
+		}
+		for _, c := range choice {
+			if strings.Contains(c, "{{") || strings.Contains(c, "{%") {
+				choice = nil // rendered choices are not known until rendered
+				break
+			}
+		}
+		s.Manifest.Vars = append(s.Manifest.Vars, Var{Name: name, Default: def, Choices: choice})
+	}
+
+	root := ""
+	ent, err := fs.ReadDir(fsys, ".")
+	if nil != err {
+		return nil, err
+	}
+	for _, e := range ent {
+		if e.IsDir() && strings.Contains(e.Name(), "{{") {
+			if root != "" {
+				return nil, fmt.Errorf("%s: more than one template directory", CookiecutterName)
+			}
+			root = e.Name()
+		}
+	}
+	if root == "" {
+		return nil, fmt.Errorf("%s: no template directory", CookiecutterName)
+	}
+	sub, err := fs.Sub(fsys, root)
+	if nil != err {
+		return nil, err
+	}
+	conv := fstest.MapFS{}
+	err = fs.WalkDir(sub, ".", func(p string, d fs.DirEntry, err error) error {
+		if nil != err || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if nil != err {
+			return err
+		}
+		b, err := fs.ReadFile(sub, p)
+		if nil != err {
+			return err
+		}
+		name, err := jinja(p)
+		if nil != err {
+			return &fs.PathError{Op: "convert", Path: p, Err: err}
+		}
+		if !IsBinary(b) && !s.binary(p) {
+			text, err := jinja(string(b))
+			if nil != err {
+				return &fs.PathError{Op: "convert", Path: p, Err: err}
+			}
+			b, name = []byte(text), name+TemplateExt
+		}
+		conv[name] = &fstest.MapFile{Data: b, Mode: info.Mode()}
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	s.FS = conv
+	return s, nil
+}
+
+// jinja returns the given Jinja template text converted to its text/template
+// equivalent. The expressions and statements supported are those commonly
+// used by cookiecutter templates: variables of the context, string literals,
+// filters and string methods, comparisons, "if" statements, comments, and
+// "raw" blocks.
+func jinja(text string) (string, error) {
+	var b strings.Builder
+	for text != "" {
+		i := strings.IndexByte(text, '{')
+		if i < 0 || i+1 >= len(text) {
+			b.WriteString(text)
+			break
+		}
+		b.WriteString(text[:i])
+		text = text[i:]
+		var end string
+		switch text[1] {
+		case '{':
+			end = "}}"
+		case '%':
+			end = "%}"
+		case '#':
+			end = "#}"
+		default:
+			b.WriteByte('{')
+			text = text[1:]
+			continue
+		}
+		j := strings.Index(text[2:], end)
+		if j < 0 {
+			return "", fmt.Errorf("unterminated %q", text[:2])
+		}
+		body := text[2 : j+2]
+		text = text[j+4:]
+		left, right := "{{", "}}"
+		if strings.HasPrefix(body, "-") {
+			body, left = body[1:], "{{- "
+		}
+		if strings.HasSuffix(body, "-") {
+			body, right = body[:len(body)-1], " -}}"
+		}
+		body = strings.TrimSpace(body)
+		switch end {
+		case "#}":
+			continue
+		case "}}":
+			act, err := jinjaExpr(body)
+			if nil != err {
+				return "", err
+			}
+			b.WriteString(left + act + right)
+			continue
+		}
+		word, rest, _ := strings.Cut(body, " ")
+		switch word {
+		case "raw":
+			k := strings.Index(text, "endraw")
+			if k < 0 {
+				return "", fmt.Errorf("unterminated raw block")
+			}
+			if k = strings.LastIndex(text[:k], "{%"); k < 0 {
+				return "", fmt.Errorf("unterminated raw block")
+			}
+			e := strings.Index(text[k:], "%}")
+			if e < 0 {
+				return "", fmt.Errorf("unterminated raw block")
+			}
+			b.WriteString(Escape(text[:k]))
+			text = text[k+e+2:]
+		case "if", "elif":
+			cond, err := jinjaExpr(rest)
+			if nil != err {
+				return "", err
+			}
+			if word == "elif" {
+				word = "else if"
+			}
+			b.WriteString(left + word + " " + cond + right)
+		case "else":
+			b.WriteString(left + "else" + right)
+		case "endif":
+			b.WriteString(left + "end" + right)
+		default:
+			return "", fmt.Errorf("unsupported statement: %s", word)
+		}
+	}
+	return b.String(), nil
+}
+
+// jinjaExpr returns the text/template pipeline equivalent to the given Jinja
+// expression.
+func jinjaExpr(expr string) (string, error) {
+	tok, err := lexJinja(expr)
+	if nil != err {
+		return "", err
+	}
+	p := &jinjaParser{tok: tok}
+	act, err := p.or()
+	if nil != err {
+		return "", err
+	}
+	if p.pos < len(p.tok) {
+		return "", fmt.Errorf("unexpected %q in expression: %s", p.tok[p.pos], expr)
+	}
+	return act, nil
+}
+
+// lexJinja returns the tokens of the given Jinja expression: names, string and
+// number literals, and operators.
+func lexJinja(expr string) ([]string, error) {
+	tok := []string{}
+	for expr = strings.TrimSpace(expr); expr != ""; expr = strings.TrimSpace(expr) {
+		switch c := expr[0]; {
+		case c == '"' || c == '\'':
+			j := strings.IndexByte(expr[1:], c)
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated string: %s", expr)
+			}
+			tok, expr = append(tok, strconv.Quote(expr[1:j+1])), expr[j+2:]
+		case strings.HasPrefix(expr, "==") || strings.HasPrefix(expr, "!="):
+			tok, expr = append(tok, expr[:2]), expr[2:]
+		case strings.ContainsRune("|().,", rune(c)):
+			tok, expr = append(tok, expr[:1]), expr[1:]
+		case c == '_' || c == '-' || 'a' <= c|0x20 && c|0x20 <= 'z' || '0' <= c && c <= '9':
+			j := 1
+			for j < len(expr) && (expr[j] == '_' || 'a' <= expr[j]|0x20 && expr[j]|0x20 <= 'z' ||
+				'0' <= expr[j] && expr[j] <= '9') {
+				j++
+			}
+			tok, expr = append(tok, expr[:j]), expr[j:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in expression", c)
+		}
+	}
+	return tok, nil
+}
+
+// jinjaFilter maps the name of each Jinja filter and string method supported
+// to the function in Funcs equivalent to it.
+var jinjaFilter = map[string]string{
+	"lower":      "lower",
+	"upper":      "upper",
+	"title":      "title",
+	"capitalize": "title",
+	"strip":      "trim",
+	"trim":       "trim",
+	"replace":    "replace",
+	"default":    "default",
+	"d":          "default",
+}
+
+// jinjaParser converts the tokens of a Jinja expression to a text/template
+// pipeline by recursive descent.
+type jinjaParser struct {
+	tok []string
+	pos int
+}
+
+// peek returns the current token of the receiver jinjaParser p, or "" at the
+// end of the expression.
+func (p *jinjaParser) peek() string {
+	if p.pos < len(p.tok) {
+		return p.tok[p.pos]
+	}
+	return ""
+}
+
+// next returns the current token of the receiver jinjaParser p and advances to
+// the next.
+func (p *jinjaParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// or parses the operands of "or", if any.
+func (p *jinjaParser) or() (string, error) {
+	return p.join("or", p.and)
+}
+
+// and parses the operands of "and", if any.
+func (p *jinjaParser) and() (string, error) {
+	return p.join("and", p.not)
+}
+
+// join parses the operands, parsed by the given function operand, of the given
+// logical operator op.
+func (p *jinjaParser) join(op string, operand func() (string, error)) (string, error) {
+	act, err := operand()
+	if nil != err {
+		return "", err
+	}
+	arg := []string{act}
+	for p.peek() == op {
+		p.next()
+		if act, err = operand(); nil != err {
+			return "", err
+		}
+		arg = append(arg, act)
+	}
+	if len(arg) == 1 {
+		return act, nil
+	}
+	return "(" + op + " " + strings.Join(arg, " ") + ")", nil
+}
+
+// not parses an optionally negated comparison.
+func (p *jinjaParser) not() (string, error) {
+	if p.peek() == "not" {
+		p.next()
+		act, err := p.not()
+		return "(not " + act + ")", err
+	}
+	return p.compare()
+}
+
+// compare parses a pipeline optionally compared to another.
+func (p *jinjaParser) compare() (string, error) {
+	act, err := p.pipeline()
+	if nil != err {
+		return "", err
+	}
+	switch op := p.peek(); op {
+	case "==", "!=":
+		p.next()
+		rhs, err := p.pipeline()
+		if nil != err {
+			return "", err
+		}
+		fn := map[string]string{"==": "eq", "!=": "ne"}[op]
+		return "(" + fn + " " + act + " " + rhs + ")", nil
+	}
+	return act, nil
+}
+
+// pipeline parses an operand followed by its string methods and filters.
+func (p *jinjaParser) pipeline() (string, error) {
+	var act string
+	switch t := p.next(); {
+	case t == "(":
+		inner, err := p.or()
+		if nil != err {
+			return "", err
+		}
+		if p.next() != ")" {
+			return "", fmt.Errorf("expected )")
+		}
+		act = inner
+	case t == "cookiecutter":
+		if p.next() != "." {
+			return "", fmt.Errorf("expected . after cookiecutter")
+		}
+		name := p.next()
+		if !ident.MatchString(name) {
+			return "", fmt.Errorf("invalid variable name: %s", name)
+		}
+		act = "." + name
+	case strings.HasPrefix(t, `"`):
+		act = t
+	case t != "" && ('0' <= t[0] && t[0] <= '9' || t[0] == '-'):
+		act = strconv.Quote(t) // the context of cookiecutter is all strings
+	case t == "true" || t == "True":
+		act = "true"
+	case t == "false" || t == "False":
+		act = "false"
+	default:
+		return "", fmt.Errorf("unsupported operand: %s", t)
+	}
+	fn := []string{}
+	for p.peek() == "." || p.peek() == "|" {
+		p.next()
+		name := p.next()
+		f, ok := jinjaFilter[name]
+		if !ok {
+			return "", fmt.Errorf("unsupported filter: %s", name)
+		}
+		arg, err := p.args()
+		if nil != err {
+			return "", err
+		}
+		fn = append(fn, strings.Join(append([]string{f}, arg...), " "))
+	}
+	if len(fn) == 0 {
+		return act, nil
+	}
+	return "(" + act + " | " + strings.Join(fn, " | ") + ")", nil
+}
+
+// args parses the parenthesized arguments of a filter or method, if any.
+func (p *jinjaParser) args() ([]string, error) {
+	arg := []string{}
+	if p.peek() != "(" {
+		return arg, nil
+	}
+	p.next()
+	for p.peek() != ")" {
+		if p.peek() == "" {
+			return nil, fmt.Errorf("expected )")
+		}
+		a, err := p.or()
+		if nil != err {
+			return nil, err
+		}
+		arg = append(arg, a)
+		if p.peek() == "," {
+			p.next()
+		}
+	}
+	p.next()
+	return arg, nil
+}
@@ -0,0 +1,185 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestJinja(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"text", "plain { text }", "plain { text }"},
+		{"variable", "{{ cookiecutter.name }}", "{{.name}}"},
+		{"string", `{{ "a" }}{{ 'b' }}`, `{{"a"}}{{"b"}}`},
+		{"number", "{{ 42 }}", `{{"42"}}`},
+		{"bool", "{{ True }}{{ false }}", "{{true}}{{false}}"},
+		{"filter", "{{ cookiecutter.name|lower }}", "{{(.name | lower)}}"},
+		{"filters", "{{ cookiecutter.name | trim | upper }}", "{{(.name | trim | upper)}}"},
+		{"method", "{{ cookiecutter.name.strip() }}", "{{(.name | trim)}}"},
+		{"filter args", `{{ cookiecutter.name|replace("-", "_") }}`, `{{(.name | replace "-" "_")}}`},
+		{"default", `{{ cookiecutter.name|d('x') }}`, `{{(.name | default "x")}}`},
+		{"eq", `{{ cookiecutter.a == "b" }}`, `{{(eq .a "b")}}`},
+		{"ne", `{{ cookiecutter.a != 'b' }}`, `{{(ne .a "b")}}`},
+		{"and", "{{ cookiecutter.a and cookiecutter.b and cookiecutter.c }}", "{{(and .a .b .c)}}"},
+		{"or", "{{ cookiecutter.a or cookiecutter.b and cookiecutter.c }}", "{{(or .a (and .b .c))}}"},
+		{"not", "{{ not cookiecutter.a or not not cookiecutter.b }}", "{{(or (not .a) (not (not .b)))}}"},
+		{"parens", "{{ (cookiecutter.a or cookiecutter.b) and cookiecutter.c }}", "{{(and (or .a .b) .c)}}"},
+		{"if", `{% if cookiecutter.a == "y" %}A{% endif %}`, `{{if (eq .a "y")}}A{{end}}`},
+		{"elif else", "{% if cookiecutter.a %}A{% elif cookiecutter.b %}B{% else %}C{% endif %}",
+			"{{if .a}}A{{else if .b}}B{{else}}C{{end}}"},
+		{"raw", "{% raw %}{{ cookiecutter.a }}{% endraw %}!", `{{"{{"}} cookiecutter.a }}!`},
+		{"comment", "a{# a comment #}b", "ab"},
+		{"trim left", "a\n{{- cookiecutter.a }}", "a\n{{- .a}}"},
+		{"trim right", "{{ cookiecutter.a -}}\nb", "{{.a -}}\nb"},
+		{"trim statement", "{%- if cookiecutter.a -%}\nA\n{%- endif %}", "{{- if .a -}}\nA\n{{- end}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jinja(tt.text)
+			if nil != err {
+				t.Fatalf("jinja(%q) = %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("jinja(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJinjaExecute(t *testing.T) {
+	vars := map[string]string{"name": " My-App ", "db": "postgres", "docker": "y"}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"{{ cookiecutter.name.strip().lower() }}", "my-app"},
+		{`{{ cookiecutter.name|trim|replace("-", "_")|upper }}`, "MY_APP"},
+		{`{{ cookiecutter.missing|default("none") }}`, "none"},
+		{`{% if cookiecutter.db == "sqlite" %}S{% elif cookiecutter.db == "postgres" %}P{% else %}N{% endif %}`, "P"},
+		{`{% if cookiecutter.docker == "y" and not cookiecutter.missing %}D{% endif %}`, "D"},
+		{"{%- if cookiecutter.docker %}\nyes\n{%- endif %}", "\nyes"},
+	}
+	for _, tt := range tests {
+		text, err := jinja(tt.text)
+		if nil != err {
+			t.Fatalf("jinja(%q) = %v", tt.text, err)
+		}
+		got, err := Execute("test", text, vars, nil)
+		if nil != err {
+			t.Fatalf("Execute(%q) = %v", text, err)
+		}
+		if got != tt.want {
+			t.Errorf("Execute(jinja(%q)) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestJinjaError(t *testing.T) {
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{"{{ cookiecutter.a", `unterminated "{{"`},
+		{"{% if cookiecutter.a", `unterminated "{%"`},
+		{"{# comment", `unterminated "{#"`},
+		{"{% raw %}{{ a }}", "unterminated raw block"},
+		{"{% for x in cookiecutter.a %}{% endfor %}", "unsupported statement: for"},
+		{"{% set x = 1 %}", "unsupported statement: set"},
+		{"{{ cookiecutter.a|slugify }}", "unsupported filter: slugify"},
+		{"{{ other.a }}", "unsupported operand: other"},
+		{`{{ "a }}`, "unterminated string"},
+		{"{{ cookiecutter.a + 1 }}", `unexpected '+' in expression`},
+		{"{{ cookiecutter.a cookiecutter.b }}", `unexpected "cookiecutter" in expression`},
+		{"{{ (cookiecutter.a }}", "expected )"},
+		{"{{ cookiecutter.a|replace('a', 'b' }}", "expected )"},
+	}
+	for _, tt := range tests {
+		_, err := jinja(tt.text)
+		if nil == err || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("jinja(%q) = %v, want error containing %q", tt.text, err, tt.err)
+		}
+	}
+}
+
+func TestLoadCookiecutter(t *testing.T) {
+	fsys := fstest.MapFS{
+		CookiecutterName: {Data: []byte(`{
+			"project_slug": "app",
+			"license": ["MIT", "BSD-3-Clause"],
+			"greeting": "Hello, {{ cookiecutter.project_slug }}",
+			"_copy_without_render": ["static/*"]
+		}`)},
+		"{{cookiecutter.project_slug}}/README.md":                           {Data: []byte("# {{ cookiecutter.project_slug }}\n{{ cookiecutter.license }}\n")},
+		"{{cookiecutter.project_slug}}/static/a.txt":                        {Data: []byte("{{ cookiecutter.project_slug }}\n")},
+		"{{cookiecutter.project_slug}}/{{cookiecutter.greeting|lower}}.txt": {Data: []byte("{{ cookiecutter.greeting }}\n")},
+	}
+	s, err := Load(fsys)
+	if nil != err {
+		t.Fatalf("Load() = %v", err)
+	}
+	for _, tt := range []struct {
+		name, def string
+		choice    []string
+	}{
+		{"project_slug", "{{.NAME}}", nil},
+		{"license", "MIT", []string{"MIT", "BSD-3-Clause"}},
+		{"greeting", "Hello, {{.project_slug}}", nil},
+	} {
+		v := s.Var(tt.name)
+		if nil == v {
+			t.Errorf("Var(%q) = nil", tt.name)
+			continue
+		}
+		if v.Default != tt.def || strings.Join(v.Choices, ",") != strings.Join(tt.choice, ",") {
+			t.Errorf("Var(%q) = %q %q, want %q %q", tt.name, v.Default, v.Choices, tt.def, tt.choice)
+		}
+	}
+
+	file, err := s.Render(map[string]string{"NAME": "demo"})
+	if nil != err {
+		t.Fatalf("Render() = %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range file {
+		got[f.Path] = string(f.Content)
+	}
+	want := map[string]string{
+		"README.md":       "# demo\nMIT\n",
+		"static/a.txt":    "{{ cookiecutter.project_slug }}\n",
+		"hello, demo.txt": "Hello, demo\n",
+	}
+	for p, w := range want {
+		if got[p] != w {
+			t.Errorf("Render() file %q = %q, want %q", p, got[p], w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("Render() = %q, want %d files", got, len(want))
+	}
+}
+
+func TestLoadCookiecutterError(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		err  string
+	}{
+		{"not object", fstest.MapFS{CookiecutterName: {Data: []byte(`[]`)}}, "expected object"},
+		{"invalid name", fstest.MapFS{CookiecutterName: {Data: []byte(`{"a-b": "x"}`)}}, "invalid variable name: a-b"},
+		{"no directory", fstest.MapFS{CookiecutterName: {Data: []byte(`{"a": "x"}`)}}, "no template directory"},
+		{"two directories", fstest.MapFS{
+			CookiecutterName:             {Data: []byte(`{"a": "x"}`)},
+			"{{cookiecutter.a}}/f":       {Data: []byte("f")},
+			"{{cookiecutter.a}}-other/f": {Data: []byte("f")},
+		}, "more than one template directory"},
+	}
+	for _, tt := range tests {
+		if _, err := Load(tt.fsys); nil == err || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: Load() = %v, want error containing %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package scaffold
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// Funcs contains the functions available to templates, a subset of those
+// provided by the Sprig library (github.com/Masterminds/sprig) with the same
+// names and signatures, so that templates written for other generators port
+// easily. The value being transformed is always the last argument.
+var Funcs = template.FuncMap{
+	// strings
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      title,
+	"untitle":    untitle,
+	"trim":       strings.TrimSpace,
+	"trimAll":    func(cutset, s string) string { return strings.Trim(s, cutset) },
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"repeat":     func(n int, s string) string { return strings.Repeat(s, max(n, 0)) },
+	"substr":     substr,
+	"trunc":      trunc,
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"quote":      quote,
+	"squote":     squote,
+	"cat":        cat,
+	"indent":     indent,
+	"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+	"snakecase":  func(s string) string { return strings.Join(words(s, strings.ToLower), "_") },
+	"kebabcase":  func(s string) string { return strings.Join(words(s, strings.ToLower), "-") },
+	"camelcase":  func(s string) string { return strings.Join(words(s, title), "") },
+	"splitList":  func(sep, s string) []interface{} { return toList(strings.Split(s, sep)) },
+	"join":       join,
+	"toString":   toString,
+
+	// lists
+	"list":    func(v ...interface{}) []interface{} { return v },
+	"first":   func(l []interface{}) interface{} { return index(l, 0) },
+	"last":    func(l []interface{}) interface{} { return index(l, len(l)-1) },
+	"rest":    func(l []interface{}) []interface{} { return slice(l, 1, len(l)) },
+	"initial": func(l []interface{}) []interface{} { return slice(l, 0, len(l)-1) },
+	"append":  func(l []interface{}, v interface{}) []interface{} { return append(slice(l, 0, len(l)), v) },
+	"prepend": func(l []interface{}, v interface{}) []interface{} { return append([]interface{}{v}, l...) },
+	"has":     has,
+	"without": without,
+	"uniq":    uniq,
+	"compact": compact,
+	"sortAlpha": func(l interface{}) []string {
+		s := toStrings(l)
+		sort.Strings(s)
+		return s
+	},
+
+	// dicts
+	"dict":   dict,
+	"get":    func(d map[string]interface{}, key string) interface{} { return orEmpty(d[key]) },
+	"set":    func(d map[string]interface{}, key string, v interface{}) map[string]interface{} { d[key] = v; return d },
+	"unset":  func(d map[string]interface{}, key string) map[string]interface{} { delete(d, key); return d },
+	"hasKey": func(d map[string]interface{}, key string) bool { _, ok := d[key]; return ok },
+	"keys":   keys,
+	"values": values,
+
+	// dates
+	"now":        time.Now,
+	"date":       date,
+	"toDate":     toDate,
+	"dateModify": dateModify,
+	"unixEpoch":  func(t time.Time) string { return fmt.Sprint(t.Unix()) },
+	"duration":   duration,
+
+	// environment
+	"env":       os.Getenv,
+	"expandenv": os.ExpandEnv,
+
+	// identifiers
+	"uuidv4": uuidv4,
+
+	// defaults
+	"default":  dfault,
+	"empty":    empty,
+	"coalesce": coalesce,
+	"ternary": func(t, f interface{}, cond bool) interface{} {
+		if cond {
+			return t
+		}
+		return f
+	},
+}
+
+// title returns the given text s with the first letter of each word in upper
+// case.
+func title(s string) string {
+	r := []rune(s)
+	for i := range r {
+		if i == 0 || !unicode.IsLetter(r[i-1]) && !unicode.IsDigit(r[i-1]) {
+			r[i] = unicode.ToUpper(r[i])
+		}
+	}
+	return string(r)
+}
+
+// untitle returns the given text s with the first letter of each word in lower
+// case.
+func untitle(s string) string {
+	r := []rune(s)
+	for i := range r {
+		if i == 0 || unicode.IsSpace(r[i-1]) {
+			r[i] = unicode.ToLower(r[i])
+		}
+	}
+	return string(r)
+}
+
+// substr returns the bytes of the given text s from index start up to, but not
+// including, index end. A negative start or end is the beginning or end of s.
+func substr(start, end int, s string) string {
+	if start < 0 {
+		return s[:min(max(end, 0), len(s))]
+	}
+	if end < 0 || end > len(s) {
+		return s[min(start, len(s)):]
+	}
+	return s[min(start, end):end]
+}
+
+// trunc returns the first n bytes of the given text s, or the last -n bytes if
+// n is negative.
+func trunc(n int, s string) string {
+	if n < 0 && len(s)+n > 0 {
+		return s[len(s)+n:]
+	}
+	if n >= 0 && len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// quote returns each of the given values v, that is not nil, as a double-quoted
+// string, separated by spaces.
+func quote(v ...interface{}) string {
+	s := []string{}
+	for _, e := range v {
+		if e != nil {
+			s = append(s, fmt.Sprintf("%q", toString(e)))
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+// squote returns each of the given values v, that is not nil, as a
+// single-quoted string, separated by spaces.
+func squote(v ...interface{}) string {
+	s := []string{}
+	for _, e := range v {
+		if e != nil {
+			s = append(s, "'"+toString(e)+"'")
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+// cat returns each of the given values v, that is not nil, as a string,
+// separated by spaces.
+func cat(v ...interface{}) string {
+	s := []string{}
+	for _, e := range v {
+		if e != nil {
+			s = append(s, toString(e))
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+// indent returns the given text s with each line indented by n spaces.
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", max(n, 0))
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// words returns the words of the given identifier or phrase s, split at spaces,
+// punctuation, and lower-to-upper case transitions, each transformed with the
+// given function f.
+func words(s string, f func(string) string) []string {
+	w := []string{}
+	r := []rune(s)
+	beg := -1
+	for i := 0; i <= len(r); i++ {
+		split := i == len(r) || !unicode.IsLetter(r[i]) && !unicode.IsDigit(r[i])
+		if !split && beg >= 0 && unicode.IsUpper(r[i]) && unicode.IsLower(r[i-1]) {
+			w, beg = append(w, f(strings.ToLower(string(r[beg:i])))), i
+		}
+		switch {
+		case split && beg >= 0:
+			w, beg = append(w, f(strings.ToLower(string(r[beg:i])))), -1
+		case !split && beg < 0:
+			beg = i
+		}
+	}
+	return w
+}
+
+// join returns the elements of the given list v as strings separated by sep.
+func join(sep string, v interface{}) string {
+	return strings.Join(toStrings(v), sep)
+}
+
+// toString returns the given value v as a string.
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case fmt.Stringer:
+		return s.String()
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// toStrings returns the elements of the given list v, or v itself if it is not
+// a list, as strings.
+func toStrings(v interface{}) []string {
+	s := []string{}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			if e := rv.Index(i).Interface(); e != nil {
+				s = append(s, toString(e))
+			}
+		}
+		return s
+	}
+	if v != nil {
+		s = append(s, toString(v))
+	}
+	return s
+}
+
+// toList returns the given strings s as a list.
+func toList(s []string) []interface{} {
+	l := make([]interface{}, len(s))
+	for i, e := range s {
+		l[i] = e
+	}
+	return l
+}
+
+// index returns the element of the given list l at index i, or nil if i is out
+// of range.
+func index(l []interface{}, i int) interface{} {
+	if i < 0 || i >= len(l) {
+		return nil
+	}
+	return l[i]
+}
+
+// slice returns a copy of the elements of the given list l from index i up to,
+// but not including, index j.
+func slice(l []interface{}, i, j int) []interface{} {
+	if i > j || j < 0 {
+		return []interface{}{}
+	}
+	return append([]interface{}{}, l[i:j]...)
+}
+
+// has returns whether or not the given list l contains needle.
+func has(needle interface{}, l []interface{}) bool {
+	for _, e := range l {
+		if reflect.DeepEqual(e, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// without returns the elements of the given list l not found in omit.
+func without(l []interface{}, omit ...interface{}) []interface{} {
+	out := []interface{}{}
+	for _, e := range l {
+		if !has(e, omit) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// uniq returns the elements of the given list l with duplicates removed.
+func uniq(l []interface{}) []interface{} {
+	out := []interface{}{}
+	for _, e := range l {
+		if !has(e, out) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// compact returns the elements of the given list l that are not empty.
+func compact(l []interface{}) []interface{} {
+	out := []interface{}{}
+	for _, e := range l {
+		if !empty(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// dict returns a dictionary of the given alternating keys and values v.
+func dict(v ...interface{}) map[string]interface{} {
+	d := map[string]interface{}{}
+	for i := 0; i < len(v); i += 2 {
+		d[toString(v[i])] = nil
+		if i+1 < len(v) {
+			d[toString(v[i])] = v[i+1]
+		}
+	}
+	return d
+}
+
+// orEmpty returns the given value v, or the empty string if v is nil.
+func orEmpty(v interface{}) interface{} {
+	if v == nil {
+		return ""
+	}
+	return v
+}
+
+// keys returns the sorted keys of all given dictionaries d.
+func keys(d ...map[string]interface{}) []string {
+	k := []string{}
+	for _, m := range d {
+		for e := range m {
+			k = append(k, e)
+		}
+	}
+	sort.Strings(k)
+	return k
+}
+
+// values returns the values of the given dictionary d, sorted by key.
+func values(d map[string]interface{}) []interface{} {
+	v := []interface{}{}
+	for _, k := range keys(d) {
+		v = append(v, d[k])
+	}
+	return v
+}
+
+// date returns the given time t, which is either a time.Time, a *time.Time, or
+// the seconds since the Unix epoch, formatted with the given Go time layout.
+func date(layout string, t interface{}) string {
+	switch v := t.(type) {
+	case time.Time:
+		return v.Format(layout)
+	case *time.Time:
+		return v.Format(layout)
+	case int:
+		return time.Unix(int64(v), 0).Format(layout)
+	case int64:
+		return time.Unix(v, 0).Format(layout)
+	case int32:
+		return time.Unix(int64(v), 0).Format(layout)
+	}
+	return time.Now().Format(layout)
+}
+
+// toDate returns the time, in the local time zone, represented by the given
+// text s formatted with the given Go time layout, or the zero time if s cannot
+// be parsed.
+func toDate(layout, s string) time.Time {
+	t, _ := time.ParseInLocation(layout, s, time.Local)
+	return t
+}
+
+// dateModify returns the given time t adjusted by the given duration d (e.g.,
+// "-1.5h"), or t itself if d cannot be parsed.
+func dateModify(d string, t time.Time) time.Time {
+	dur, err := time.ParseDuration(d)
+	if nil != err {
+		return t
+	}
+	return t.Add(dur)
+}
+
+// duration returns the given number of seconds sec, either an integer or its
+// decimal representation, as a time.Duration string (e.g., "1m35s").
+func duration(sec interface{}) string {
+	var n int64
+	switch v := sec.(type) {
+	case int:
+		n = int64(v)
+	case int64:
+		n = v
+	default:
+		fmt.Sscan(toString(v), &n)
+	}
+	return (time.Duration(n) * time.Second).String()
+}
+
+// uuidv4 returns a new random (version 4) UUID in its canonical text form.
+func uuidv4() string {
+	var u [16]byte
+	_, _ = rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40 // version 4
+	u[8] = u[8]&0x3f | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// dfault returns the first of the given values given, or d if it is empty.
+func dfault(d interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || empty(given[0]) {
+		return d
+	}
+	return given[0]
+}
+
+// empty returns whether or not the given value v is the zero value of its type
+// or an empty collection.
+func empty(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return true
+	}
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
+
+// coalesce returns the first of the given values v that is not empty.
+func coalesce(v ...interface{}) interface{} {
+	for _, e := range v {
+		if !empty(e) {
+			return e
+		}
+	}
+	return nil
+}
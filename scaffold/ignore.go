@@ -0,0 +1,146 @@
+package scaffold
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// IgnoreName is the name of the optional file in the root of a template set
+// listing the patterns of files that are kept alongside the template set, e.g.,
+// its tests, fixtures, and documentation, but are not part of it. It is not
+// rendered into the module itself.
+//
+// Each line is a pattern with the syntax of a .gitignore file, except that "**"
+// is not supported: blank lines and lines beginning with "#" are ignored, a
+// leading "!" includes files excluded by an earlier pattern, a trailing "/"
+// matches only directories (and thus every file they contain), and a pattern
+// containing no other "/" matches the name of a file or directory at any depth;
+// otherwise, it matches the path relative to the root.
+const IgnoreName = ".mkgoignore"
+
+// ignoreRule is a pattern of the file at IgnoreName.
+type ignoreRule struct {
+	pattern string
+	negate  bool // includes matching files excluded by earlier rules
+	dir     bool // matches only directories
+	base    bool // matches the name of files at any depth
+}
+
+// parseIgnore returns the rules of the given content b of a file at IgnoreName.
+func parseIgnore(b []byte) ([]ignoreRule, error) {
+	rule := []ignoreRule{}
+	for scan := bufio.NewScanner(bytes.NewReader(b)); scan.Scan(); {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := ignoreRule{}
+		if r.negate = strings.HasPrefix(line, "!"); r.negate {
+			line = line[1:]
+		}
+		if r.dir = strings.HasSuffix(line, "/"); r.dir {
+			line = strings.TrimRight(line, "/")
+		}
+		r.base = !strings.Contains(line, "/")
+		r.pattern = strings.TrimPrefix(line, "/")
+		if _, err := path.Match(r.pattern, ""); nil != err || r.pattern == "" {
+			return nil, &fs.PathError{Op: "parse", Path: IgnoreName,
+				Err: errors.New("invalid pattern: " + scan.Text())}
+		}
+		rule = append(rule, r)
+	}
+	return rule, nil
+}
+
+// ignoreFS is a template set whose files matching the rules of its file at
+// IgnoreName, and that file itself, do not exist.
+type ignoreFS struct {
+	fs.FS
+	rule []ignoreRule
+}
+
+// withIgnore returns the given fsys without the files ignored by its file at
+// IgnoreName, or fsys itself if it has none.
+func withIgnore(fsys fs.FS) (fs.FS, error) {
+	b, err := fs.ReadFile(fsys, IgnoreName)
+	if nil != err {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fsys, nil
+		}
+		return nil, err
+	}
+	rule, err := parseIgnore(b)
+	if nil != err {
+		return nil, err
+	}
+	return &ignoreFS{FS: fsys, rule: rule}, nil
+}
+
+// ignored returns whether or not the rules of the receiver ignoreFS f match the
+// file at the given slash-separated path p, a directory if dir is true.
+func (f *ignoreFS) ignored(p string, dir bool) bool {
+	if p == IgnoreName {
+		return true
+	}
+	skip := false
+	for _, r := range f.rule {
+		if r.dir && !dir {
+			continue
+		}
+		name := p
+		if r.base {
+			name = path.Base(p)
+		}
+		if ok, _ := path.Match(r.pattern, name); ok {
+			skip = !r.negate
+		}
+	}
+	return skip
+}
+
+// ignoredPath returns whether or not the file at the given slash-separated
+// path p, or any of its parent directories, is ignored by the receiver
+// ignoreFS f.
+func (f *ignoreFS) ignoredPath(p string) bool {
+	if p == "." {
+		return false
+	}
+	for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+		if f.ignored(dir, true) {
+			return true
+		}
+	}
+	info, err := fs.Stat(f.FS, p)
+	return f.ignored(p, nil == err && info.IsDir())
+}
+
+// Open opens the file at the given path name, unless it is ignored.
+func (f *ignoreFS) Open(name string) (fs.File, error) {
+	if fs.ValidPath(name) && f.ignoredPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.FS.Open(name)
+}
+
+// ReadDir returns the entries of the directory at the given path name that are
+// not ignored, unless it is ignored.
+func (f *ignoreFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if fs.ValidPath(name) && f.ignoredPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	ent, err := fs.ReadDir(f.FS, name)
+	if nil != err {
+		return nil, err
+	}
+	out := ent[:0]
+	for _, e := range ent {
+		if !f.ignored(path.Join(name, e.Name()), e.IsDir()) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
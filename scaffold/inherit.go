@@ -0,0 +1,133 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing/fstest"
+)
+
+// PartialDir is the directory of a template set containing its partials: named
+// templates that files with extension TemplateExt may execute, e.g., with
+// {{template "flags" .}}. Each partial is named after its path relative
+// to PartialDir, without extension TemplateExt (e.g., "_partials/flags.tmpl"
+// is named "flags"), and a single trailing newline is removed from its
+// content. Partials are not rendered into the module, and those of a base are
+// replaced by the partials of the same name of the template set extending it.
+const PartialDir = "_partials"
+
+// partials returns the definitions, as text/template actions, of each partial
+// of the receiver Set s, sorted by name.
+func (s *Set) partials() ([]byte, error) {
+	var b strings.Builder
+	err := fs.WalkDir(s.FS, PartialDir, func(p string, d fs.DirEntry, err error) error {
+		if nil != err || d.IsDir() {
+			return err
+		}
+		text, err := fs.ReadFile(s.FS, p)
+		if nil != err {
+			return err
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(p, PartialDir+"/"), TemplateExt)
+		b.WriteString(`{{define ` + strconv.Quote(name) + `}}`)
+		b.Write(bytes.TrimSuffix(text, []byte("\n")))
+		b.WriteString(`{{end}}`)
+		return nil
+	})
+	if nil != err && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// Extend returns the template set inheriting from the given base the files and
+// manifest not overridden by the receiver Set s:
+//
+//   - every file of base is included, unless s has a file at the same path
+//     (including its partials, which thus replace those of base);
+//   - the name, description, and base are those of s, and the minimum version is
+//     that of s, or of base if s declares none;
+//   - the modules required by either are required, with the version given
+//     by s for a module required by both;
+//   - the variables declared by base are declared, in order, replaced by any
+//     variable of s with the same name, followed by those only s declares; and
+//   - the file rules of base apply, followed by those of s, the directories
+//     declared by either are created, and the hooks of base run before those
+//     of s, which is remote if either is.
+func (s *Set) Extend(base *Set) (*Set, error) {
+	out := fstest.MapFS{}
+	for _, src := range []fs.FS{base.FS, s.FS} {
+		err := fs.WalkDir(src, ".", func(p string, d fs.DirEntry, err error) error {
+			if nil != err || p == "." || p == ManifestName {
+				return err
+			}
+			info, err := d.Info()
+			if nil != err {
+				return err
+			}
+			if d.IsDir() {
+				// directories are copied, too, so that empty ones are kept.
+				out[p] = &fstest.MapFile{Mode: info.Mode()}
+				return nil
+			}
+			b, err := fs.ReadFile(src, p)
+			if nil != err {
+				return err
+			}
+			out[p] = &fstest.MapFile{Data: b, Mode: info.Mode()}
+			return nil
+		})
+		if nil != err {
+			return nil, err
+		}
+	}
+	m := Manifest{
+		Name:        s.Manifest.Name,
+		Description: s.Manifest.Description,
+		MinVersion:  s.Manifest.MinVersion,
+		Base:        s.Manifest.Base,
+		Files:       append(append([]FileRule{}, base.Manifest.Files...), s.Manifest.Files...),
+		Dirs:        append(append([]string{}, base.Manifest.Dirs...), s.Manifest.Dirs...),
+		Hooks: Hooks{
+			Pre:  append(append([]string{}, base.Manifest.Hooks.Pre...), s.Manifest.Hooks.Pre...),
+			Post: append(append([]string{}, base.Manifest.Hooks.Post...), s.Manifest.Hooks.Post...),
+		},
+	}
+	if m.MinVersion == "" {
+		m.MinVersion = base.Manifest.MinVersion
+	}
+	own := map[string]string{}
+	for _, r := range s.Manifest.Requires {
+		mod, _, _ := strings.Cut(r, "@")
+		own[mod] = r
+	}
+	for _, r := range base.Manifest.Requires {
+		if mod, _, _ := strings.Cut(r, "@"); own[mod] == "" {
+			m.Requires = append(m.Requires, r)
+		}
+	}
+	m.Requires = append(m.Requires, s.Manifest.Requires...)
+	vars := map[string]int{}
+	for _, v := range s.Manifest.Vars {
+		vars[v.Name] = 1
+	}
+	for _, v := range base.Manifest.Vars {
+		if vars[v.Name] == 0 {
+			m.Vars = append(m.Vars, v)
+			continue
+		}
+		for _, o := range s.Manifest.Vars {
+			if o.Name == v.Name {
+				m.Vars, vars[v.Name] = append(m.Vars, o), 2
+			}
+		}
+	}
+	for _, v := range s.Manifest.Vars {
+		if vars[v.Name] == 1 {
+			m.Vars = append(m.Vars, v)
+		}
+	}
+	return &Set{FS: out, Manifest: m, Remote: s.Remote || base.Remote}, nil
+}
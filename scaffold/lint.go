@@ -0,0 +1,195 @@
+package scaffold
+
+import (
+	"go/format"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Problem is a problem found in a file of a template set by Lint.
+type Problem struct {
+	Path    string // slash-separated, relative to the template set
+	Line    int    // 1-based, or 0 if the problem concerns the whole file
+	Message string
+}
+
+// String returns the receiver Problem p as "path:line: message".
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return p.Path + ":" + strconv.Itoa(p.Line) + ": " + p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// opening matches the beginning of a placeholder token, capturing the name of
+// its variable.
+var opening = regexp.MustCompile(`__([A-Za-z][A-Za-z0-9]*(?:_[A-Za-z0-9]+)*)`)
+
+// Lint returns the problems found in every file of the receiver Set s,
+// regardless of manifest conditions:
+//
+//   - tokens of variables that are neither declared by its manifest nor
+//     defined in the given example vars, which are likely misspelled,
+//     suggesting the most similar name;
+//   - tokens with an undefined function or malformed pipeline, and tokens of a
+//     defined variable missing their closing underscores;
+//   - files with extension TemplateExt, and partials, that are not valid
+//     text/templates or fail to execute;
+//   - Go source files that cannot be parsed, and thus formatted with gofmt,
+//     once rendered with the example vars and the manifest's defaults; and
+//   - a script that fails when run with those vars.
+func (s *Set) Lint(vars map[string]string) ([]Problem, error) {
+	vars = s.Defaults(vars)
+	prob := []Problem{}
+	if out, err := s.Run(vars, MapLookup(vars)); nil != err {
+		prob = append(prob, Problem{Path: ScriptName, Message: err.Error()})
+	} else {
+		vars = out
+	}
+	known := map[string]bool{}
+	for n := range vars {
+		known[n] = true
+	}
+	for _, v := range s.Manifest.Vars {
+		known[v.Name] = true
+	}
+	for _, r := range s.Manifest.Files {
+		for _, n := range Names(r.When) {
+			known[n] = true
+		}
+	}
+	define, err := s.partials()
+	if nil != err {
+		return nil, err
+	}
+	err = fs.WalkDir(s.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if nil != err || d.IsDir() || p == ManifestName || p == ScriptName {
+			return err
+		}
+		b, err := fs.ReadFile(s.FS, p)
+		if nil != err {
+			return err
+		}
+		if IsBinary(b) || s.binary(p) {
+			return nil
+		}
+		prob = append(prob, lintTokens(p, string(b), known)...)
+		text, name := string(b), p
+		switch {
+		case strings.HasPrefix(p, PartialDir+"/"):
+			name = "" // partials are fragments, not checked as Go source files
+		case strings.HasSuffix(p, TemplateExt):
+			name, text = strings.TrimSuffix(p, TemplateExt), text+string(define)
+		default:
+			text = Escape(text)
+		}
+		// every variable is defined, so that tokens of the variables expected
+		// from the user are also rendered.
+		all := map[string]string{}
+		for n := range known {
+			all[n] = "x"
+		}
+		for n, v := range vars {
+			all[n] = v
+		}
+		out, err := Execute(p, text, all, nil)
+		if nil != err {
+			prob = append(prob, Problem{Path: p, Message: err.Error()})
+			return nil
+		}
+		if path.Ext(name) == ".go" {
+			if _, err := format.Source([]byte(out)); nil != err {
+				prob = append(prob, Problem{Path: p, Message: "not valid Go once rendered: " + err.Error()})
+			}
+		}
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	return prob, nil
+}
+
+// lintTokens returns the problems found in the placeholder tokens of the given
+// text of the file at path p, whose variables are valid if known.
+func lintTokens(p, text string, known map[string]bool) []Problem {
+	prob := []Problem{}
+	for i, line := range strings.Split(text, "\n") {
+		span := token.FindAllStringSubmatchIndex(line, -1)
+		for _, m := range span {
+			// escaped tokens are not placeholders.
+			if m[3] > m[2] {
+				continue
+			}
+			name, pipe := line[m[4]:m[5]], line[m[6]:m[7]]
+			if pipe != "" {
+				if _, err := action(name, pipe); nil != err {
+					prob = append(prob, Problem{p, i + 1, "invalid token " + line[m[0]:m[1]] + ": " + err.Error()})
+					continue
+				}
+			}
+			if !known[name] && placeholder(name, pipe) {
+				msg := "unknown variable " + name
+				if near := nearest(name, known); near != "" {
+					msg += " (did you mean " + near + "?)"
+				}
+				prob = append(prob, Problem{p, i + 1, msg})
+			}
+		}
+	next:
+		for _, m := range opening.FindAllStringSubmatchIndex(line, -1) {
+			for _, t := range span {
+				if m[0] >= t[0] && m[0] < t[1] {
+					continue next
+				}
+			}
+			if name := line[m[2]:m[3]]; known[name] {
+				prob = append(prob, Problem{p, i + 1, "unterminated token __" + name})
+			}
+		}
+	}
+	return prob
+}
+
+// nearest returns the name in known most similar to the given name, if its
+// edit distance is at most 2 (ignoring case), or the empty string otherwise.
+func nearest(name string, known map[string]bool) string {
+	cand := []string{}
+	for n := range known {
+		cand = append(cand, n)
+	}
+	sort.Strings(cand)
+	best, dist := "", 3
+	for _, n := range cand {
+		if d := distance(strings.ToUpper(name), strings.ToUpper(n)); d < dist {
+			best, dist = n, d
+		}
+	}
+	return best
+}
+
+// distance returns the Levenshtein edit distance between the given strings a
+// and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
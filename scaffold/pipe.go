@@ -0,0 +1,95 @@
+package scaffold
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// action returns the text/template action equivalent to the placeholder token
+// with the given pipeline of functions pipe, each element separated by "|",
+// beginning with the value of the variable with the given name. Each element of
+// pipe is the name of a function in Funcs followed by its arguments, separated
+// by spaces, with the result of the previous element given as the last
+// argument.
+//
+// For example, the token "__NAME|replace "-" "_"|upper__" is equivalent to the
+// action "{{.NAME | replace "-" "_" | upper}}".
+func action(name, pipe string) (string, error) {
+	act := "{{." + name
+	for _, elem := range strings.Split(strings.TrimPrefix(pipe, "|"), "|") {
+		arg, err := splitArgs(elem)
+		if nil != err {
+			return "", err
+		}
+		if len(arg) == 0 {
+			return "", fmt.Errorf("empty function in pipeline")
+		}
+		f, ok := Funcs[arg[0]]
+		if !ok {
+			return "", fmt.Errorf("undefined function: %s", arg[0])
+		}
+		act += " | " + arg[0]
+		typ := reflect.TypeOf(f)
+		// the value piped from the previous element is the final argument.
+		if n, given := typ.NumIn(), len(arg); given < n-1 || (given != n && !typ.IsVariadic()) {
+			return "", fmt.Errorf("%s: want %d arguments before the piped value, have %d", arg[0], n-1, given-1)
+		}
+		for i, a := range arg[1:] {
+			lit, err := literal(typ, i, a)
+			if nil != err {
+				return "", fmt.Errorf("%s: argument %d: %w", arg[0], i+1, err)
+			}
+			act += " " + lit
+		}
+	}
+	return act + "}}", nil
+}
+
+// splitArgs returns the space-separated fields of the given text s, where each
+// field may be a double-quoted Go string literal.
+func splitArgs(s string) ([]string, error) {
+	arg := []string{}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] == '"' {
+			q, err := strconv.QuotedPrefix(s)
+			if nil != err {
+				return nil, fmt.Errorf("invalid string: %s", s)
+			}
+			u, _ := strconv.Unquote(q)
+			arg, s = append(arg, u), s[len(q):]
+			continue
+		}
+		i := strings.IndexAny(s, " \t")
+		if i < 0 {
+			i = len(s)
+		}
+		arg, s = append(arg, s[:i]), s[i:]
+	}
+	return arg, nil
+}
+
+// literal returns the given argument a of a pipeline as a text/template
+// literal of the type of the ith parameter of the function with the given type
+// typ, whose number of parameters is checked by action.
+func literal(typ reflect.Type, i int, a string) (string, error) {
+	n := typ.NumIn()
+	t := typ.In(min(i, n-1))
+	if typ.IsVariadic() && i >= n-1 {
+		t = t.Elem()
+	}
+	var err error
+	switch t.Kind() {
+	case reflect.Int:
+		_, err = strconv.Atoi(a)
+	case reflect.Bool:
+		_, err = strconv.ParseBool(a)
+	default:
+		return strconv.Quote(a), nil
+	}
+	if nil != err {
+		return "", fmt.Errorf("cannot use %q as %s", a, t)
+	}
+	return a, nil
+}
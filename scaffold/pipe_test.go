@@ -0,0 +1,41 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAction(t *testing.T) {
+	tests := []struct {
+		pipe string
+		want string // the action, or the prefix of the error if "!"-prefixed
+	}{
+		{`|upper`, `{{.NAME | upper}}`},
+		{`|replace "-" "_"|upper`, `{{.NAME | replace "-" "_" | upper}}`},
+		{`|repeat 2`, `{{.NAME | repeat 2}}`},
+		{`|cat "a" "b"`, `{{.NAME | cat "a" "b"}}`},
+		{`|upper "x"`, `!upper: want 0 arguments`},
+		{`|replace "-"`, `!replace: want 2 arguments`},
+		{`|repeat x`, `!repeat: argument 1`},
+		{`|nope`, `!undefined function`},
+	}
+	for _, tt := range tests {
+		got, err := action("NAME", tt.pipe)
+		if msg, ok := strings.CutPrefix(tt.want, "!"); ok {
+			if nil == err || !strings.HasPrefix(err.Error(), msg) {
+				t.Errorf("action(%q) = %q, %v, want error %q", tt.pipe, got, err, msg)
+			}
+			continue
+		}
+		if nil != err || got != tt.want {
+			t.Errorf("action(%q) = %q, %v, want %q", tt.pipe, got, err, tt.want)
+		}
+	}
+}
+
+func TestLintArity(t *testing.T) {
+	prob := lintTokens("a.txt", `__NAME|upper "x"__`, map[string]bool{"NAME": true})
+	if len(prob) != 1 || !strings.Contains(prob[0].Message, "want 0 arguments") {
+		t.Errorf("lintTokens() = %v, want the arguments of upper reported", prob)
+	}
+}
@@ -0,0 +1,139 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	gotoken "go/token"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing/fstest"
+
+	"golang.org/x/mod/modfile"
+)
+
+// majorSuffix matches the major version suffix (e.g., "/v2") of a module path.
+var majorSuffix = regexp.MustCompile(`/v[0-9]+$`)
+
+// ModulePath returns the module path declared by the go.mod file in the root of
+// the given fsys, or the empty string if it has none.
+func ModulePath(fsys fs.FS) string {
+	b, err := fs.ReadFile(fsys, "go.mod")
+	if nil != err {
+		return ""
+	}
+	return modfile.ModulePath(b)
+}
+
+// Rewrite returns a copy of the files of the Go module in the given fsys whose
+// module path, import paths, and package identifiers are changed to refer to
+// the module with the given path mod and package name, similar to gonew
+// (golang.org/x/tools/cmd/gonew).
+//
+// The module directive of go.mod is replaced, as is the prefix of each import
+// path of a package in the module. The package in the root of the module, if
+// its name is that of the original module, is renamed, along with every
+// reference to it by importing packages. Files that are not Go source files
+// are copied unchanged.
+func Rewrite(fsys fs.FS, mod, name string) (fs.FS, error) {
+	old := ModulePath(fsys)
+	if old == "" {
+		return nil, fmt.Errorf("go.mod: no module path")
+	}
+	oldName := path.Base(majorSuffix.ReplaceAllString(old, ""))
+	out := fstest.MapFS{}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if nil != err || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if nil != err {
+			return err
+		}
+		b, err := fs.ReadFile(fsys, p)
+		if nil != err {
+			return err
+		}
+		switch {
+		case p == "go.mod":
+			if b, err = rewriteModule(p, b, mod); nil != err {
+				return err
+			}
+		case strings.HasSuffix(p, ".go"):
+			if b, err = rewriteSource(p, b, old, mod, oldName, name); nil != err {
+				return err
+			}
+		}
+		out[p] = &fstest.MapFile{Data: b, Mode: info.Mode()}
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	return out, nil
+}
+
+// rewriteModule returns the given go.mod file src, at path p, with its module
+// directive changed to the module with path mod.
+func rewriteModule(p string, src []byte, mod string) ([]byte, error) {
+	f, err := modfile.ParseLax(p, src, nil)
+	if nil != err {
+		return nil, err
+	}
+	if err := f.AddModuleStmt(mod); nil != err {
+		return nil, err
+	}
+	return f.Format()
+}
+
+// rewriteSource returns the given Go source file src, at path p, with the
+// imports of the module with path old changed to the module with path mod,
+// and the root package named oldName renamed to name.
+func rewriteSource(p string, src []byte, old, mod, oldName, name string) ([]byte, error) {
+	fset := gotoken.NewFileSet()
+	f, err := parser.ParseFile(fset, p, src, parser.ParseComments)
+	if nil != err {
+		return nil, err
+	}
+	changed := false
+	rename := oldName != name && oldName != "main" && gotoken.IsIdentifier(name)
+	if rename && path.Dir(p) == "." && f.Name.Name == oldName {
+		f.Name.Name, changed = name, true
+	}
+	for _, imp := range f.Imports {
+		ipath, err := strconv.Unquote(imp.Path.Value)
+		if nil != err {
+			continue
+		}
+		if ipath != old && !strings.HasPrefix(ipath, old+"/") {
+			continue
+		}
+		imp.Path.Value, changed = strconv.Quote(mod+strings.TrimPrefix(ipath, old)), true
+		if ipath != old || !rename || imp.Name != nil {
+			continue
+		}
+		// the unresolved identifiers of selector expressions refer to imported
+		// packages.
+		ast.Inspect(f, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if x, ok := sel.X.(*ast.Ident); ok && x.Name == oldName && x.Obj == nil {
+					x.Name = name
+				}
+			}
+			return true
+		})
+	}
+	if !changed {
+		return src, nil
+	}
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, f); nil != err {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
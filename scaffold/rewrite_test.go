@@ -0,0 +1,35 @@
+package scaffold
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestModulePath(t *testing.T) {
+	tests := []struct {
+		gomod string
+		want  string
+	}{
+		{"module example.com/a\n", "example.com/a"},
+		{"module example.com/a // comment\n", "example.com/a"},
+		{"module \"example.com/a\"\n", "example.com/a"},
+		{"// module example.com/b\nmodule example.com/a\n", "example.com/a"},
+		{"go 1.21\n", ""},
+	}
+	for _, tt := range tests {
+		fsys := fstest.MapFS{"go.mod": {Data: []byte(tt.gomod)}}
+		if got := ModulePath(fsys); got != tt.want {
+			t.Errorf("ModulePath(%q) = %q, want %q", tt.gomod, got, tt.want)
+		}
+		if tt.want == "" {
+			continue
+		}
+		out, err := Rewrite(fsys, "example.com/c", "c")
+		if nil != err {
+			t.Fatalf("Rewrite(%q) = %v", tt.gomod, err)
+		}
+		if got := ModulePath(out); got != "example.com/c" {
+			t.Errorf("Rewrite(%q) has module %q, want example.com/c", tt.gomod, got)
+		}
+	}
+}
@@ -0,0 +1,693 @@
+// Package scaffold loads and renders template sets, the trees of files from
+// which mkgo creates modules.
+//
+// A template set is any fs.FS. Every file in it is rendered into the module at
+// the same relative path, except for the optional manifest, template.yaml,
+// which describes the template set itself.
+package scaffold
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestName is the name of the manifest file in the root of a template set.
+const ManifestName = "template.yaml"
+
+// TemplateExt is the extension of files in a template set executed as a
+// text/template, which is removed from their paths. Only the placeholder
+// tokens of other files are replaced.
+const TemplateExt = ".tmpl"
+
+// Manifest describes a template set.
+type Manifest struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+
+	// MinVersion is the minimum semantic version of mkgo (e.g., "0.5.0")
+	// required to render the template set.
+	MinVersion string `yaml:"min-version"`
+
+	// Base is the template set extended by this one, given by name, directory,
+	// or module query, whose files and manifest are inherited (see Extend).
+	Base string `yaml:"base"`
+
+	// Requires lists the modules, each with an optional version suffix (e.g.,
+	// "github.com/spf13/cobra@v1.8.0"), required by the generated module.
+	Requires []string `yaml:"requires"`
+
+	// Vars declares the variables referenced by the template set.
+	Vars []Var `yaml:"vars"`
+
+	// Files lists rules applied to the files of the template set whose paths
+	// match each rule's Path.
+	Files []FileRule `yaml:"files"`
+
+	// Dirs lists the slash-separated paths, which may contain placeholder
+	// tokens and actions, of directories created in the module even if no
+	// file is rendered into them.
+	Dirs []string `yaml:"dirs"`
+
+	// Hooks lists the commands run from the directory of the module before
+	// and after its files are written.
+	Hooks Hooks `yaml:"hooks"`
+}
+
+// Hooks lists the commands run before and after the files of a template set
+// are written. Each command is split into arguments by HookArgs, and is not
+// run by a shell; each argument may contain placeholder tokens and actions.
+type Hooks struct {
+	Pre  []string `yaml:"pre"`
+	Post []string `yaml:"post"`
+}
+
+// HookArgs returns the arguments of the given command of a hook, separated by
+// white space, where each may be a double-quoted Go string literal.
+func HookArgs(cmd string) ([]string, error) {
+	arg, err := splitArgs(cmd)
+	if nil == err && len(arg) == 0 {
+		err = errors.New("empty command")
+	}
+	return arg, err
+}
+
+// Var declares a variable referenced by a template set.
+type Var struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"` // shown when prompting for its value
+	Default     string `yaml:"default"`
+	Required    bool   `yaml:"required"` // must be given a value
+
+	// Type is the type of its value: "string" (the default), "bool", or "int".
+	// A bool is given as any of "true", "yes", "1", "false", "no", or "0" and
+	// rendered as "true" or "", so that it may be tested with {{if}}.
+	Type string `yaml:"type"`
+
+	// Choices lists the values it may be given, if not any of its type.
+	Choices []string `yaml:"choices"`
+}
+
+// Types of the value of a variable.
+const (
+	TypeString = "string"
+	TypeBool   = "bool"
+	TypeInt    = "int"
+)
+
+// Parse returns the given value of the receiver Var v, converted to its
+// canonical form, or an error if it is not a valid value of its type or choices.
+func (v *Var) Parse(value string) (string, error) {
+	switch v.Type {
+	case TypeBool:
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "true", "yes", "1":
+			return "true", nil
+		case "", "false", "no", "0":
+			return "", nil
+		}
+		return "", fmt.Errorf("not a bool: %q", value)
+	case TypeInt:
+		if _, err := strconv.Atoi(strings.TrimSpace(value)); nil != err {
+			return "", fmt.Errorf("not an int: %q", value)
+		}
+		value = strings.TrimSpace(value)
+	}
+	if len(v.Choices) == 0 {
+		return value, nil
+	}
+	for _, c := range v.Choices {
+		if c == value {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("not one of %s: %q", strings.Join(v.Choices, ", "), value)
+}
+
+// FileRule is a rule applied to the files of a template set whose path matches
+// Path, either as a path.Match pattern or as a parent directory.
+type FileRule struct {
+	Path string `yaml:"path"`
+
+	// When is a condition expression (see Eval) that must be true for the
+	// matching files to be rendered.
+	When string `yaml:"when"`
+
+	// Binary indicates the matching files are copied verbatim instead of
+	// rendered. Files containing a NUL byte are always copied verbatim.
+	Binary bool `yaml:"binary"`
+
+	// Base64 indicates the matching files are base64-encoded and copied,
+	// decoded, verbatim. The suffix ".b64", if present, is removed from the
+	// path of each file.
+	Base64 bool `yaml:"base64"`
+
+	// Mode is the octal permission bits (e.g., "0755") of the matching files.
+	// By default, files are created with mode 0664, or 0775 if any executable
+	// bit is set on the file in the template set.
+	Mode string `yaml:"mode"`
+}
+
+// matches returns whether or not the given slash-separated file path p is
+// matched by the receiver FileRule r.
+func (r *FileRule) matches(p string) bool {
+	pat := strings.TrimSuffix(r.Path, "/")
+	if ok, _ := path.Match(pat, p); ok {
+		return true
+	}
+	return strings.HasPrefix(p, pat+"/")
+}
+
+// Set is a template set loaded from an fs.FS.
+type Set struct {
+	FS       fs.FS
+	Manifest Manifest
+	// Remote is true if the set, or any set it extends, was downloaded (e.g.,
+	// from a module proxy) rather than found locally, so that its hooks are
+	// not trusted.
+	Remote bool
+
+	skip []FileRule // files excluded by its script (see Run)
+}
+
+// File is a file of a template set, selected for rendering.
+type File struct {
+	Path    string // slash-separated, relative to the module
+	Mode    fs.FileMode
+	Content []byte
+	Binary  bool // copied verbatim, without replacing placeholder tokens
+	Action  bool // executed as a text/template, not only replacing tokens
+}
+
+// Load returns the template set in the given fsys, reading its manifest if one
+// exists, without the files ignored by its file at IgnoreName. A cookiecutter
+// template, having a context file but no manifest, is converted to a template
+// set.
+func Load(fsys fs.FS) (*Set, error) {
+	fsys, err := withIgnore(fsys)
+	if nil != err {
+		return nil, err
+	}
+	s := &Set{FS: fsys}
+	b, err := fs.ReadFile(fsys, ManifestName)
+	if nil != err {
+		if errors.Is(err, fs.ErrNotExist) {
+			if b, err := fs.ReadFile(fsys, CookiecutterName); nil == err {
+				return loadCookiecutter(fsys, b)
+			}
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, &s.Manifest); nil != err {
+		return nil, &fs.PathError{Op: "parse", Path: ManifestName, Err: err}
+	}
+	if err := s.Manifest.Validate(); nil != err {
+		return nil, &fs.PathError{Op: "validate", Path: ManifestName, Err: err}
+	}
+	return s, nil
+}
+
+// validVersion returns whether or not the given version v is a semantic
+// version, with or without its "v" prefix.
+func validVersion(v string) bool {
+	return semver.IsValid("v" + strings.TrimPrefix(v, "v"))
+}
+
+// Validate returns an error describing the first invalid field of the receiver
+// Manifest m, or nil if every field is valid.
+func (m *Manifest) Validate() error {
+	if m.MinVersion != "" && !validVersion(m.MinVersion) {
+		return fmt.Errorf("min-version: invalid semantic version: %s", m.MinVersion)
+	}
+	for _, r := range m.Requires {
+		mod, ver, _ := strings.Cut(r, "@")
+		if mod == "" || strings.ContainsAny(mod, " \t") || strings.HasSuffix(r, "@") ||
+			(ver != "" && !validVersion(ver) && ver != "latest") {
+			return fmt.Errorf("requires: invalid module: %q", r)
+		}
+	}
+	seen := map[string]bool{}
+	for _, v := range m.Vars {
+		switch {
+		case !ident.MatchString(v.Name):
+			return fmt.Errorf("vars: invalid name: %q", v.Name)
+		case seen[v.Name]:
+			return fmt.Errorf("vars: duplicate name: %s", v.Name)
+		case v.Required && v.Default != "":
+			return fmt.Errorf("vars: %s: required variable has a default", v.Name)
+		case v.Type != "" && v.Type != TypeString && v.Type != TypeBool && v.Type != TypeInt:
+			return fmt.Errorf("vars: %s: invalid type: %s", v.Name, v.Type)
+		case v.Type == TypeBool && len(v.Choices) > 0:
+			return fmt.Errorf("vars: %s: bool variable has choices", v.Name)
+		}
+		for _, c := range v.Choices {
+			if _, err := v.Parse(c); nil != err {
+				return fmt.Errorf("vars: %s: invalid choice: %w", v.Name, err)
+			}
+		}
+		if v.Default != "" && !strings.Contains(v.Default, "{{") {
+			if _, err := v.Parse(v.Default); nil != err {
+				return fmt.Errorf("vars: %s: invalid default: %w", v.Name, err)
+			}
+		}
+		seen[v.Name] = true
+	}
+	for _, d := range m.Dirs {
+		if d == "" || path.IsAbs(d) || path.Clean(d) == ".." || strings.HasPrefix(path.Clean(d), "../") {
+			return fmt.Errorf("dirs: invalid path: %q", d)
+		}
+	}
+	for _, h := range append(append([]string{}, m.Hooks.Pre...), m.Hooks.Post...) {
+		if _, err := HookArgs(h); nil != err {
+			return fmt.Errorf("hooks: %w", err)
+		}
+	}
+	for _, r := range m.Files {
+		if r.Path == "" {
+			return fmt.Errorf("files: missing path")
+		}
+		if _, err := path.Match(r.Path, ""); nil != err {
+			return fmt.Errorf("files: %s: %w", r.Path, err)
+		}
+		if r.When != "" {
+			if _, err := Eval(r.When, MapLookup(nil)); nil != err {
+				return fmt.Errorf("files: %s: when: %w", r.Path, err)
+			}
+		}
+		if r.Mode != "" {
+			if m, err := strconv.ParseUint(r.Mode, 8, 32); nil != err || m > 0777 {
+				return fmt.Errorf("files: %s: invalid mode: %s", r.Path, r.Mode)
+			}
+		}
+	}
+	return nil
+}
+
+// Missing returns the names of the variables declared required by the receiver
+// Set s that are not defined in the given variables vars.
+func (s *Set) Missing(vars map[string]string) []string {
+	name := []string{}
+	for _, v := range s.Manifest.Vars {
+		if _, ok := vars[v.Name]; v.Required && !ok {
+			name = append(name, v.Name)
+		}
+	}
+	return name
+}
+
+// Check returns a copy of the given variables vars with the value of each
+// variable declared by the receiver Set s converted to its canonical form (see
+// Var.Parse), or an error naming the first variable whose value is invalid.
+func (s *Set) Check(vars map[string]string) (map[string]string, error) {
+	out := map[string]string{}
+	for k, v := range vars {
+		out[k] = v
+	}
+	for i := range s.Manifest.Vars {
+		v := &s.Manifest.Vars[i]
+		if val, ok := out[v.Name]; ok {
+			p, err := v.Parse(val)
+			if nil != err {
+				return nil, fmt.Errorf("%s: %w", v.Name, err)
+			}
+			out[v.Name] = p
+		}
+	}
+	return out, nil
+}
+
+// Var returns the declaration of the variable of the receiver Set s with the
+// given name, or nil if it declares none.
+func (s *Set) Var(name string) *Var {
+	for i := range s.Manifest.Vars {
+		if s.Manifest.Vars[i].Name == name {
+			return &s.Manifest.Vars[i]
+		}
+	}
+	return nil
+}
+
+// Files returns the unrendered files of the receiver Set s, sorted by path,
+// whose manifest conditions are all true. The given lookup resolves the names
+// referenced by each condition. The partials of s are appended to the content
+// of each file with extension TemplateExt, as definitions of named templates.
+func (s *Set) Files(lookup Lookup) ([]File, error) {
+	define, err := s.partials()
+	if nil != err {
+		return nil, err
+	}
+	file := []File{}
+	err = fs.WalkDir(s.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if nil != err {
+			return err
+		}
+		if d.IsDir() && p == PartialDir {
+			return fs.SkipDir
+		}
+		if d.IsDir() || p == ManifestName || p == ScriptName || s.skipped(p) {
+			return nil
+		}
+		b, err := fs.ReadFile(s.FS, p)
+		if nil != err {
+			return err
+		}
+		f := File{Path: p, Mode: 0664, Content: b, Binary: IsBinary(b)}
+		if info, err := d.Info(); nil == err && info.Mode()&0111 != 0 {
+			f.Mode = 0775
+		}
+		for _, r := range s.Manifest.Files {
+			if !r.matches(p) {
+				continue
+			}
+			if r.When != "" {
+				ok, err := Eval(r.When, lookup)
+				if nil != err {
+					return &fs.PathError{Op: "when", Path: p, Err: err}
+				}
+				if !ok {
+					return nil
+				}
+			}
+			if r.Mode != "" {
+				m, err := strconv.ParseUint(r.Mode, 8, 32)
+				if nil != err || m > 0777 {
+					return &fs.PathError{Op: "mode", Path: p, Err: fmt.Errorf("invalid mode: %s", r.Mode)}
+				}
+				f.Mode = fs.FileMode(m)
+			}
+			f.Binary = f.Binary || r.Binary || r.Base64
+			if r.Base64 && f.Path == p {
+				dec, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(b)))
+				if nil != err {
+					return &fs.PathError{Op: "decode", Path: p, Err: err}
+				}
+				f.Path, f.Content = strings.TrimSuffix(p, ".b64"), dec
+			}
+		}
+		if !f.Binary && strings.HasSuffix(f.Path, TemplateExt) {
+			f.Path, f.Action = strings.TrimSuffix(f.Path, TemplateExt), true
+			f.Content = append(f.Content[:len(f.Content):len(f.Content)], define...)
+		}
+		file = append(file, f)
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Dirs returns the unrendered paths, sorted, of the directories of the receiver
+// Set s created even if no file is rendered into them: those declared by its
+// manifest, and every empty directory of s. The directories matching a
+// manifest rule whose condition is false, or skipped by its script, are
+// excluded. The given lookup resolves the names referenced by each condition.
+func (s *Set) Dirs(lookup Lookup) ([]string, error) {
+	dir := append([]string{}, s.Manifest.Dirs...)
+	err := fs.WalkDir(s.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if nil != err || !d.IsDir() || p == "." {
+			return err
+		}
+		if p == PartialDir {
+			return fs.SkipDir
+		}
+		if e, err := fs.ReadDir(s.FS, p); nil == err && len(e) == 0 {
+			dir = append(dir, p)
+		}
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	out, seen := []string{}, map[string]bool{}
+next:
+	for _, p := range dir {
+		p = path.Clean(p)
+		if s.skipped(p) {
+			continue
+		}
+		for _, r := range s.Manifest.Files {
+			if r.When == "" || !r.matches(p) {
+				continue
+			}
+			ok, err := Eval(r.When, lookup)
+			if nil != err {
+				return nil, &fs.PathError{Op: "when", Path: p, Err: err}
+			}
+			if !ok {
+				continue next
+			}
+		}
+		if !seen[p] {
+			out, seen[p] = append(out, p), true
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// Render returns the files of the receiver Set s with every placeholder token
+// replaced by its value in vars, and those with extension TemplateExt executed
+// as a text/template with vars as data. As when mkgo renders it, the defaults
+// of its variables are applied (see Defaults), every required variable must be
+// defined (see Missing), and the value of each variable it declares is checked
+// (see Check) before its script, if any, runs (see Run). The names in vars are
+// also used to evaluate manifest conditions.
+func (s *Set) Render(vars map[string]string) ([]File, error) {
+	vars = s.Defaults(vars)
+	if miss := s.Missing(vars); len(miss) > 0 {
+		return nil, fmt.Errorf("missing required variables: %s", strings.Join(miss, ", "))
+	}
+	vars, err := s.Check(vars)
+	if nil != err {
+		return nil, err
+	}
+	if vars, err = s.Run(vars, MapLookup(vars)); nil != err {
+		return nil, err
+	}
+	file, err := s.Files(MapLookup(vars))
+	if nil != err {
+		return nil, err
+	}
+	for i := range file {
+		if file[i].Binary {
+			continue
+		}
+		text := string(file[i].Content)
+		if !file[i].Action {
+			text = Escape(text)
+		}
+		text, err := Execute(file[i].Path, text, vars, nil)
+		if nil != err {
+			return nil, err
+		}
+		file[i].Content = []byte(text)
+	}
+	for i := range file {
+		p, err := Execute(file[i].Path, file[i].Path, vars, nil)
+		if nil != err {
+			return nil, err
+		}
+		file[i].Path = p
+	}
+	return file, nil
+}
+
+// Defaults returns a copy of the given variables vars with the default value of
+// each variable declared by the receiver Set s added if not already defined. A
+// default containing actions is executed as a text/template with the variables
+// defined before it as data.
+func (s *Set) Defaults(vars map[string]string) map[string]string {
+	def := map[string]string{}
+	for k, v := range vars {
+		def[k] = v
+	}
+	for _, v := range s.Manifest.Vars {
+		if _, ok := def[v.Name]; !ok && v.Default != "" {
+			def[v.Name] = v.Default
+			if strings.Contains(v.Default, "{{") {
+				if d, err := Execute(v.Name, v.Default, def, nil); nil == err {
+					def[v.Name] = d
+				}
+			}
+		}
+	}
+	return def
+}
+
+// token matches a placeholder token, capturing the "!" escaping it, if any,
+// before the name of its variable, that name, and its pipeline of functions,
+// if any.
+var token = regexp.MustCompile(`__(!*)([A-Za-z][A-Za-z0-9_]*?)((?:\|[^|\n]+?)*)__`)
+
+// placeholder returns whether or not a token of the variable with the given
+// name and pipeline pipe, if any, is likely a placeholder: one with a pipeline,
+// or whose name has no lower case letters, unlike, e.g., "__init__".
+func placeholder(name, pipe string) bool {
+	return pipe != "" || name == strings.ToUpper(name)
+}
+
+// ident matches the name of a variable.
+var ident = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// Refs returns the sorted names of every variable referenced by the receiver
+// Set s, either as placeholder tokens in its files or as names in its manifest
+// conditions.
+func (s *Set) Refs() ([]string, error) {
+	seen := map[string]bool{}
+	for _, r := range s.Manifest.Files {
+		for _, n := range Names(r.When) {
+			seen[n] = true
+		}
+	}
+	// conditions are ignored when discovering variables, so that every file is
+	// searched regardless of the options it depends on.
+	file := []File{}
+	err := fs.WalkDir(s.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if nil != err || d.IsDir() || p == ManifestName || p == ScriptName {
+			return err
+		}
+		b, err := fs.ReadFile(s.FS, p)
+		if nil != err {
+			return err
+		}
+		file = append(file, File{Path: p, Content: b, Binary: IsBinary(b) || s.binary(p)})
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	for _, f := range file {
+		for _, m := range token.FindAllStringSubmatch(f.Path, -1) {
+			if m[1] == "" {
+				seen[m[2]] = true
+			}
+		}
+		if f.Binary {
+			continue
+		}
+		for _, m := range token.FindAllSubmatch(f.Content, -1) {
+			if len(m[1]) == 0 {
+				seen[string(m[2])] = true
+			}
+		}
+	}
+	name := []string{}
+	for n := range seen {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+	return name, nil
+}
+
+// When returns the conditions of every manifest rule of the receiver Set s
+// matching the file at the given slash-separated path p, all of which must be
+// true for the file to be rendered.
+func (s *Set) When(p string) []string {
+	when := []string{}
+	for _, r := range s.Manifest.Files {
+		if r.When != "" && r.matches(p) {
+			when = append(when, r.When)
+		}
+	}
+	return when
+}
+
+// binary returns whether or not the manifest of the receiver Set s marks the
+// file at the given path p as binary.
+func (s *Set) binary(p string) bool {
+	for _, r := range s.Manifest.Files {
+		if (r.Binary || r.Base64) && r.matches(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBinary returns whether or not the given file content b appears to be
+// binary data, i.e., its first 8000 bytes contain a NUL byte or are not valid
+// UTF-8 (e.g., images, icons, and archives).
+func IsBinary(b []byte) bool {
+	if len(b) > 8000 {
+		b = b[:8000]
+		// the last rune may be cut short.
+		for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(b[i]) {
+				if !utf8.FullRune(b[i:]) {
+					b = b[:i]
+				}
+				break
+			}
+		}
+	}
+	return bytes.IndexByte(b, 0) >= 0 || !utf8.Valid(b)
+}
+
+// Trace records the names of the variables consumed, functions called, and
+// tokens left unresolved while rendering, each in order of first occurrence.
+type Trace struct {
+	Vars       []string
+	Funcs      []string
+	Unresolved []string
+}
+
+// Placeholders returns the tokens left unresolved recorded in the receiver Trace
+// t that are likely placeholders (see placeholder). A nil t has none.
+func (t *Trace) Placeholders() []string {
+	tok := []string{}
+	if t == nil {
+		return tok
+	}
+	for _, u := range t.Unresolved {
+		if m := token.FindStringSubmatch(u); m != nil && placeholder(m[2], m[3]) {
+			tok = append(tok, u)
+		}
+	}
+	return tok
+}
+
+// add appends the given element e to the given list, if not already present.
+func add(list *[]string, e string) {
+	for _, s := range *list {
+		if s == e {
+			return
+		}
+	}
+	*list = append(*list, e)
+}
+
+// addVar records the variable with the given name in the receiver Trace t, if
+// not nil.
+func (t *Trace) addVar(name string) {
+	if t != nil {
+		add(&t.Vars, name)
+	}
+}
+
+// addFunc records the function with the given name in the receiver Trace t, if
+// not nil.
+func (t *Trace) addFunc(name string) {
+	if t != nil {
+		add(&t.Funcs, name)
+	}
+}
+
+// addUnresolved records the given unresolved token tok in the receiver Trace t,
+// if not nil.
+func (t *Trace) addUnresolved(tok string) {
+	if t != nil {
+		add(&t.Unresolved, tok)
+	}
+}
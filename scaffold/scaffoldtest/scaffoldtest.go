@@ -0,0 +1,148 @@
+// Package scaffoldtest provides helpers for testing template sets.
+package scaffoldtest
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ardnew/mkgo/scaffold"
+)
+
+// UpdateEnv is the name of the environment variable that, if set to a
+// non-empty value, causes Golden to rewrite the golden files instead of
+// comparing against them.
+const UpdateEnv = "SCAFFOLDTEST_UPDATE"
+
+// Render returns an in-memory file system containing the files of the template
+// set in the given templateFS rendered with the given variables vars.
+func Render(t testing.TB, templateFS fs.FS, vars map[string]string) fstest.MapFS {
+	t.Helper()
+	set, err := scaffold.Load(templateFS)
+	if nil != err {
+		t.Fatalf("cannot load template set: %v", err)
+	}
+	file, err := set.Render(vars)
+	if nil != err {
+		t.Fatalf("cannot render template set: %v", err)
+	}
+	out := fstest.MapFS{}
+	for _, f := range file {
+		out[f.Path] = &fstest.MapFile{Data: f.Content, Mode: f.Mode}
+	}
+	return out
+}
+
+// Golden renders the template set in the given templateFS with the given
+// variables vars and reports, as test errors, every file that is missing from,
+// not present in, or different in content or executable mode from the files in
+// the given directory goldenDir. Only the executable bits of modes are
+// compared, since those are the only ones kept by git.
+//
+// If the environment variable named by UpdateEnv is set, the rendered files are
+// instead written to goldenDir, and every other file in goldenDir, which must
+// be removed by hand, is reported as a test error.
+func Golden(t testing.TB, templateFS fs.FS, vars map[string]string, goldenDir string) {
+	t.Helper()
+	got := Render(t, templateFS, vars)
+	if os.Getenv(UpdateEnv) != "" {
+		update(t, got, goldenDir)
+	}
+	want := readGolden(t, goldenDir)
+	for _, p := range sortedKeys(got) {
+		w, ok := want[p]
+		switch {
+		case !ok:
+			t.Errorf("%s: unexpected file (not in %s)", p, goldenDir)
+		case !bytes.Equal(got[p].Data, w.Data):
+			t.Errorf("%s: content differs from golden file%s", p, diffLine(got[p].Data, w.Data))
+		case got[p].Mode&0111 != w.Mode&0111:
+			t.Errorf("%s: mode %v differs from golden file mode %v", p, got[p].Mode.Perm(), w.Mode.Perm())
+		}
+	}
+	for _, p := range sortedKeys(want) {
+		if _, ok := got[p]; !ok {
+			t.Errorf("%s: missing file (in %s)", p, goldenDir)
+		}
+	}
+}
+
+// readGolden returns the files, with their content and mode, in the given
+// directory goldenDir.
+func readGolden(t testing.TB, goldenDir string) fstest.MapFS {
+	t.Helper()
+	want := fstest.MapFS{}
+	golden := os.DirFS(goldenDir)
+	err := fs.WalkDir(golden, ".", func(p string, d fs.DirEntry, err error) error {
+		if nil != err || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if nil != err {
+			return err
+		}
+		b, err := fs.ReadFile(golden, p)
+		want[p] = &fstest.MapFile{Data: b, Mode: info.Mode()}
+		return err
+	})
+	if nil != err {
+		t.Fatalf("cannot read golden files: %v", err)
+	}
+	return want
+}
+
+// update writes each of the given rendered files got, with its mode, to the
+// given directory goldenDir. Other files in goldenDir are left alone.
+func update(t testing.TB, got fstest.MapFS, goldenDir string) {
+	t.Helper()
+	for _, p := range sortedKeys(got) {
+		full := filepath.Join(goldenDir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), os.ModePerm); nil != err {
+			t.Fatalf("cannot create directory: %v", err)
+		}
+		mode := got[p].Mode.Perm()
+		if err := os.WriteFile(full, got[p].Data, mode); nil != err {
+			t.Fatalf("cannot write golden file: %v", err)
+		}
+		// the mode of an existing file is not changed by os.WriteFile.
+		if err := os.Chmod(full, mode); nil != err {
+			t.Fatalf("cannot change golden file mode: %v", err)
+		}
+	}
+}
+
+// diffLine returns a description of the first line that differs between the
+// given content got and want, or of their trailing newlines if no line differs.
+func diffLine(got, want []byte) string {
+	g, w := strings.Split(string(got), "\n"), strings.Split(string(want), "\n")
+	for i := 0; i < len(g) || i < len(w); i++ {
+		var gl, wl string
+		if i < len(g) {
+			gl = g[i]
+		}
+		if i < len(w) {
+			wl = w[i]
+		}
+		if gl != wl {
+			return "\n\tline " + strconv.Itoa(i+1) + ":\n\t\tgot:  " + gl + "\n\t\twant: " + wl
+		}
+	}
+	return "\n\ttrailing newlines differ"
+}
+
+// sortedKeys returns the sorted paths of the given files m.
+func sortedKeys(m fstest.MapFS) []string {
+	key := []string{}
+	for k := range m {
+		key = append(key, path.Clean(k))
+	}
+	sort.Strings(key)
+	return key
+}
@@ -0,0 +1,136 @@
+package scaffoldtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testSet is a template set with a file executed as a text/template and a
+// file whose placeholder tokens are replaced, rendered into testdata/golden
+// with NAME "world".
+var testSet = fstest.MapFS{
+	"hello.txt.tmpl": {Data: []byte("Hello, {{.NAME}}!\n")},
+	"docs/README.md": {Data: []byte("# __NAME__\n")},
+}
+
+// recorder is a testing.TB recording the errors reported by Golden instead of
+// failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRender(t *testing.T) {
+	got := Render(t, testSet, map[string]string{"NAME": "world"})
+	for p, want := range map[string]string{"hello.txt": "Hello, world!\n", "docs/README.md": "# world\n"} {
+		f, ok := got[p]
+		if !ok {
+			t.Errorf("Render() has no file %s", p)
+			continue
+		}
+		if string(f.Data) != want {
+			t.Errorf("Render() file %s = %q, want %q", p, f.Data, want)
+		}
+	}
+	if len(got) != 2 {
+		t.Errorf("Render() = %d files, want 2", len(got))
+	}
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, testSet, map[string]string{"NAME": "world"}, filepath.Join("testdata", "golden"))
+}
+
+func TestGoldenMismatch(t *testing.T) {
+	set := fstest.MapFS{
+		"hello.txt.tmpl": testSet["hello.txt.tmpl"],
+		"extra.txt":      {Data: []byte("extra\n")},
+	}
+	r := &recorder{TB: t}
+	Golden(r, set, map[string]string{"NAME": "gopher"}, filepath.Join("testdata", "golden"))
+	want := []string{
+		"extra.txt: unexpected file",
+		"hello.txt: content differs from golden file\n\tline 1:\n\t\tgot:  Hello, gopher!\n\t\twant: Hello, world!",
+		"docs/README.md: missing file",
+	}
+	if len(r.errors) != len(want) {
+		t.Fatalf("Golden() reported %d errors, want %d: %q", len(r.errors), len(want), r.errors)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(r.errors[i], w) {
+			t.Errorf("Golden() error %d = %q, want prefix %q", i, r.errors[i], w)
+		}
+	}
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "golden")
+	stale := filepath.Join(dir, "stale.txt")
+	if err := os.MkdirAll(dir, os.ModePerm); nil != err {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("stale\n"), 0664); nil != err {
+		t.Fatal(err)
+	}
+	t.Setenv(UpdateEnv, "1")
+	r := &recorder{TB: t}
+	Golden(r, testSet, map[string]string{"NAME": "world"}, dir)
+	// a golden file that is not rendered is reported, not removed.
+	if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], "stale.txt: missing file") {
+		t.Errorf("Golden() with $%s = %q, want stale.txt reported", UpdateEnv, r.errors)
+	}
+	if _, err := os.Stat(stale); nil != err {
+		t.Errorf("Golden() with $%s removed golden file %s", UpdateEnv, stale)
+	}
+	if err := os.Remove(stale); nil != err {
+		t.Fatal(err)
+	}
+	t.Setenv(UpdateEnv, "")
+	r = &recorder{TB: t}
+	Golden(r, testSet, map[string]string{"NAME": "world"}, dir)
+	if len(r.errors) > 0 {
+		t.Errorf("Golden() after update = %q, want no errors", r.errors)
+	}
+}
+
+func TestGoldenMode(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "golden")
+	set := fstest.MapFS{"run.sh": {Data: []byte("#!/bin/sh\n"), Mode: 0755}}
+	t.Setenv(UpdateEnv, "1")
+	Golden(t, set, map[string]string{}, dir)
+	if info, err := os.Stat(filepath.Join(dir, "run.sh")); nil != err || info.Mode()&0111 == 0 {
+		t.Errorf("Golden() with $%s did not write run.sh executable", UpdateEnv)
+	}
+	t.Setenv(UpdateEnv, "")
+	r := &recorder{TB: t}
+	Golden(r, fstest.MapFS{"run.sh": {Data: set["run.sh"].Data}}, map[string]string{}, dir)
+	if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], "run.sh: mode") {
+		t.Errorf("Golden() = %q, want run.sh mode reported", r.errors)
+	}
+}
+
+func TestDiffLine(t *testing.T) {
+	tests := []struct {
+		got, want string
+		diff      string
+	}{
+		{"a\nb\n", "a\nc\n", "\n\tline 2:\n\t\tgot:  b\n\t\twant: c"},
+		{"a\n", "a\nb\n", "\n\tline 2:\n\t\tgot:  \n\t\twant: b"},
+		{"a", "a\n", "\n\ttrailing newlines differ"},
+	}
+	for _, tt := range tests {
+		if got := diffLine([]byte(tt.got), []byte(tt.want)); got != tt.diff {
+			t.Errorf("diffLine(%q, %q) = %q, want %q", tt.got, tt.want, got, tt.diff)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// ScriptName is the name of the optional Starlark (https://starlark-lang.org)
+// script in the root of a template set, executed by Run before its files are
+// rendered. The script is not rendered into the module.
+const ScriptName = "generate.star"
+
+// scriptSteps is the maximum number of computation steps of a script, so that
+// a script that does not terminate fails instead.
+const scriptSteps = 10_000_000
+
+// Run executes the script of the receiver Set s, if it has one, and returns the
+// given variables vars with those it defines or replaces. Files matching any
+// pattern skipped by the script, in its last run, are then excluded by Files,
+// as if their manifest conditions were false.
+//
+// The script runs in a sandbox, without access to the file system, network, or
+// environment, and without load statements. Its global environment predeclares:
+//
+//   - vars, a dict of the variables, whose entries the script may add, modify,
+//     or delete; each value is converted to a string afterward, where True is
+//     "true" and False and None are empty;
+//   - flag(name), the value of the command-line flag or variable with the
+//     given name, resolved by the given lookup, or None if undefined; and
+//   - skip(pattern, ...), which excludes the files matching each pattern,
+//     either a path.Match pattern or a parent directory, as with FileRule.
+func (s *Set) Run(vars map[string]string, lookup Lookup) (map[string]string, error) {
+	s.skip = nil
+	src, err := fs.ReadFile(s.FS, ScriptName)
+	if nil != err {
+		if errors.Is(err, fs.ErrNotExist) {
+			return vars, nil
+		}
+		return nil, err
+	}
+	dict := starlark.NewDict(len(vars))
+	for k, v := range vars {
+		if err := dict.SetKey(starlark.String(k), starlark.String(v)); nil != err {
+			return nil, err
+		}
+	}
+	skip := starlark.NewBuiltin("skip", func(_ *starlark.Thread, b *starlark.Builtin,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if len(kwargs) > 0 {
+			return nil, fmt.Errorf("%s: unexpected keyword arguments", b.Name())
+		}
+		for _, a := range args {
+			pat, ok := starlark.AsString(a)
+			if !ok {
+				return nil, fmt.Errorf("%s: pattern is %s, not string", b.Name(), a.Type())
+			}
+			s.skip = append(s.skip, FileRule{Path: pat})
+		}
+		return starlark.None, nil
+	})
+	flag := starlark.NewBuiltin("flag", func(_ *starlark.Thread, b *starlark.Builtin,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name string
+		if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &name); nil != err {
+			return nil, err
+		}
+		if v, ok := lookup(name); ok {
+			return starlark.String(v), nil
+		}
+		return starlark.None, nil
+	})
+	thread := &starlark.Thread{Name: ScriptName}
+	thread.SetMaxExecutionSteps(scriptSteps)
+	_, err = starlark.ExecFileOptions(&syntax.FileOptions{TopLevelControl: true}, thread, ScriptName, src,
+		starlark.StringDict{"vars": dict, "flag": flag, "skip": skip})
+	if nil != err {
+		var eval *starlark.EvalError
+		if errors.As(err, &eval) {
+			err = errors.New(eval.Backtrace())
+		}
+		return nil, &fs.PathError{Op: "run", Path: ScriptName, Err: err}
+	}
+	out := map[string]string{}
+	for _, kv := range dict.Items() {
+		k, ok := starlark.AsString(kv[0])
+		if !ok {
+			return nil, &fs.PathError{Op: "run", Path: ScriptName,
+				Err: fmt.Errorf("vars: key is %s, not string", kv[0].Type())}
+		}
+		switch v := kv[1].(type) {
+		case starlark.String:
+			out[k] = string(v)
+		case starlark.Bool:
+			if v {
+				out[k] = "true"
+			} else {
+				out[k] = ""
+			}
+		case starlark.NoneType:
+			out[k] = ""
+		default:
+			out[k] = v.String()
+		}
+	}
+	return out, nil
+}
+
+// skipped returns whether or not the file at the given slash-separated path p
+// of the receiver Set s is excluded by its script.
+func (s *Set) skipped(p string) bool {
+	for i := range s.skip {
+		if s.skip[i].matches(p) {
+			return true
+		}
+	}
+	return false
+}
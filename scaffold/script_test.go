@@ -0,0 +1,83 @@
+package scaffold
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestRunSkip(t *testing.T) {
+	s, err := Load(fstest.MapFS{
+		ScriptName: {Data: []byte(`if vars["DB"] != "yes":
+    skip("db.txt")
+`)},
+		"db.txt":  {Data: []byte("db\n")},
+		"app.txt": {Data: []byte("app\n")},
+	})
+	if nil != err {
+		t.Fatalf("Load() = %v", err)
+	}
+	for _, tt := range []struct {
+		db   string
+		want int
+	}{
+		{"no", 1},
+		{"yes", 2},
+	} {
+		// the files skipped by a run are not excluded by the next.
+		file, err := s.Render(map[string]string{"DB": tt.db})
+		if nil != err {
+			t.Fatalf("Render(DB=%s) = %v", tt.db, err)
+		}
+		if len(file) != tt.want {
+			t.Errorf("Render(DB=%s) = %d files, want %d", tt.db, len(file), tt.want)
+		}
+	}
+}
+
+func TestRenderFlagDefault(t *testing.T) {
+	s, err := Load(fstest.MapFS{
+		ManifestName: {Data: []byte(`vars:
+  - name: PORT
+    default: "8080"
+`)},
+		ScriptName: {Data: []byte(`vars["ADDR"] = ":" + flag("PORT")
+`)},
+		"addr.txt.tmpl": {Data: []byte("{{.ADDR}}")},
+	})
+	if nil != err {
+		t.Fatalf("Load() = %v", err)
+	}
+	file, err := s.Render(map[string]string{})
+	if nil != err {
+		t.Fatalf("Render() = %v", err)
+	}
+	if len(file) != 1 || string(file[0].Content) != ":8080" {
+		t.Errorf("Render() = %v, want addr.txt with :8080", file)
+	}
+}
+
+func TestRenderOrder(t *testing.T) {
+	s, err := Load(fstest.MapFS{
+		ManifestName: {Data: []byte(`vars:
+  - name: DB
+    type: bool
+    required: true
+`)},
+		ScriptName:    {Data: []byte(`vars["SEEN"] = vars["DB"]` + "\n")},
+		"db.txt.tmpl": {Data: []byte("{{.SEEN}}")},
+	})
+	if nil != err {
+		t.Fatalf("Load() = %v", err)
+	}
+	if _, err := s.Render(map[string]string{}); nil == err {
+		t.Errorf("Render() without DB = nil, want error")
+	}
+	// the script sees the value of DB once checked.
+	file, err := s.Render(map[string]string{"DB": "yes"})
+	if nil != err {
+		t.Fatalf("Render() = %v", err)
+	}
+	if len(file) != 1 || string(file[0].Content) != "true" {
+		t.Errorf("Render() = %v, want db.txt with true", file)
+	}
+}
@@ -0,0 +1,137 @@
+package scaffold
+
+import (
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Execute returns the result of executing the given text, named name in error
+// messages, as a text/template with the given vars as its data and the
+// functions in Funcs, recording in the given trace, if not nil, the variables
+// consumed, functions called, and tokens left unresolved. A variable undefined
+// in vars has the empty value.
+//
+// For compatibility with templates written before actions were supported, each
+// placeholder token is first replaced by its equivalent action: the value of
+// key "NAME" in vars replaces token "__NAME__", and a token may also transform
+// the value of its variable with a pipeline of functions, e.g.,
+// "__NAME|upper__". Tokens of undefined variables are not replaced, and a token
+// escaped by "!" following its leading underscores is replaced by the token
+// without one "!", e.g., "__!NAME__" by "__NAME__" (see QuoteTokens).
+func Execute(name, text string, vars map[string]string, trace *Trace) (string, error) {
+	tmpl, err := template.New(name).Funcs(Funcs).Option("missingkey=zero").
+		Parse(shim(text, vars, trace))
+	if nil != err {
+		return "", err
+	}
+	if nil != tmpl.Tree {
+		trace.walk(tmpl.Tree.Root, vars)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); nil != err {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Escape returns a copy of the given text with every action delimiter quoted,
+// so that Execute replaces only its placeholder tokens.
+func Escape(text string) string {
+	return strings.ReplaceAll(text, "{{", `{{"{{"}}`)
+}
+
+// QuoteTokens returns a copy of the given text with every token likely a
+// placeholder (see placeholder), or already escaped, escaped once more, so
+// that Execute replaces each by itself.
+func QuoteTokens(text string) string {
+	return token.ReplaceAllStringFunc(text, func(tok string) string {
+		m := token.FindStringSubmatch(tok)
+		if m[1] == "" && !placeholder(m[2], m[3]) {
+			return tok
+		}
+		return "__!" + tok[2:]
+	})
+}
+
+// shim returns a copy of the given text with every placeholder token of a
+// variable defined in vars, or having a pipeline, replaced by its equivalent
+// action, recording in the given trace the tokens left unresolved. An escaped
+// token is replaced by the token without one "!".
+func shim(text string, vars map[string]string, trace *Trace) string {
+	return token.ReplaceAllStringFunc(text, func(tok string) string {
+		m := token.FindStringSubmatch(tok)
+		if m[1] != "" {
+			return Escape("__" + m[1][1:] + tok[2+len(m[1]):])
+		}
+		name, pipe := m[2], m[3]
+		if pipe == "" {
+			if _, ok := vars[name]; !ok {
+				trace.addUnresolved(tok)
+				return Escape(tok)
+			}
+			return "{{." + name + "}}"
+		}
+		act, err := action(name, pipe)
+		if nil != err {
+			trace.addUnresolved(tok + ": " + err.Error())
+			return Escape(tok)
+		}
+		return act
+	})
+}
+
+// walk records in the receiver Trace t, if not nil, the variables defined in
+// vars and the functions in Funcs referenced by the given parse tree node and
+// its descendants.
+func (t *Trace) walk(node parse.Node, vars map[string]string) {
+	if t == nil || node == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n != nil {
+			for _, c := range n.Nodes {
+				t.walk(c, vars)
+			}
+		}
+	case *parse.ActionNode:
+		t.walk(n.Pipe, vars)
+	case *parse.IfNode:
+		t.walkBranch(&n.BranchNode, vars)
+	case *parse.RangeNode:
+		t.walkBranch(&n.BranchNode, vars)
+	case *parse.WithNode:
+		t.walkBranch(&n.BranchNode, vars)
+	case *parse.TemplateNode:
+		t.walk(n.Pipe, vars)
+	case *parse.PipeNode:
+		if n != nil {
+			for _, c := range n.Cmds {
+				t.walk(c, vars)
+			}
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			t.walk(a, vars)
+		}
+	case *parse.ChainNode:
+		t.walk(n.Node, vars)
+	case *parse.FieldNode:
+		if _, ok := vars[n.Ident[0]]; ok {
+			t.addVar(n.Ident[0])
+		}
+	case *parse.IdentifierNode:
+		if _, ok := Funcs[n.Ident]; ok {
+			t.addFunc(n.Ident)
+		}
+	}
+}
+
+// walkBranch records in the receiver Trace t the variables and functions
+// referenced by the given branch node n, as with walk.
+func (t *Trace) walkBranch(n *parse.BranchNode, vars map[string]string) {
+	t.walk(n.Pipe, vars)
+	t.walk(n.List, vars)
+	t.walk(n.ElseList, vars)
+}
@@ -0,0 +1,42 @@
+package scaffold
+
+import "testing"
+
+func TestQuoteTokens(t *testing.T) {
+	vars := map[string]string{"NAME": "app", "init": "x"}
+	tests := []struct {
+		text  string
+		quote string
+	}{
+		{"__NAME__", "__!NAME__"},
+		{"__NAME|upper__", "__!NAME|upper__"},
+		{"__init__", "__init__"},
+		{"__!NAME__", "__!!NAME__"},
+	}
+	for _, tt := range tests {
+		got := QuoteTokens(tt.text)
+		if got != tt.quote {
+			t.Errorf("QuoteTokens(%q) = %q, want %q", tt.text, got, tt.quote)
+		}
+		// a quoted text is rendered as itself, except for the lower case
+		// names left alone.
+		want := tt.text
+		if got == tt.text {
+			want = vars[tt.text[2:len(tt.text)-2]]
+		}
+		if out, err := Execute("test", got, vars, nil); nil != err || out != want {
+			t.Errorf("Execute(%q) = %q, %v, want %q", got, out, err, want)
+		}
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	trace := &Trace{}
+	if _, err := Execute("test", "__NAME__ __init__ __A|upper__ __!B__", map[string]string{}, trace); nil != err {
+		t.Fatalf("Execute() = %v", err)
+	}
+	got := trace.Placeholders()
+	if len(got) != 1 || got[0] != "__NAME__" {
+		t.Errorf("Placeholders() = %q, want [__NAME__]", got)
+	}
+}
@@ -0,0 +1,83 @@
+package scaffold
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lookup returns the value of the flag or variable with the given name, and
+// whether or not it is defined.
+type Lookup func(name string) (string, bool)
+
+// MapLookup returns a Lookup of the variables in the given map vars.
+func MapLookup(vars map[string]string) Lookup {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+// Eval returns the value of the given condition expression, resolving each name
+// it references with the given lookup.
+//
+// An expression is one or more terms joined by "&&" or "||", where "&&" binds
+// tighter. Each term is either a name, true if it is defined with a value other
+// than "", "0", or "false"; or a comparison "name == value" or "name != value".
+// Any term may be negated with a leading "!".
+func Eval(expr string, lookup Lookup) (bool, error) {
+	for _, or := range strings.Split(expr, "||") {
+		all := true
+		for _, and := range strings.Split(or, "&&") {
+			ok, err := term(strings.TrimSpace(and), lookup)
+			if nil != err {
+				return false, fmt.Errorf("%q: %w", expr, err)
+			}
+			all = all && ok
+		}
+		if all {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Names returns the names referenced by the given condition expression, in the
+// order they appear.
+func Names(expr string) []string {
+	name := []string{}
+	for _, or := range strings.Split(expr, "||") {
+		for _, and := range strings.Split(or, "&&") {
+			t := strings.TrimLeft(strings.TrimSpace(and), "! ")
+			if i := strings.IndexAny(t, "=!"); i >= 0 {
+				t = t[:i]
+			}
+			if t = strings.TrimSpace(t); t != "" {
+				name = append(name, t)
+			}
+		}
+	}
+	return name
+}
+
+// term returns the value of a single term of a condition expression.
+func term(t string, lookup Lookup) (bool, error) {
+	if strings.HasPrefix(t, "!") {
+		ok, err := term(strings.TrimSpace(t[1:]), lookup)
+		return !ok, err
+	}
+	for _, op := range []string{"==", "!="} {
+		if i := strings.Index(t, op); i >= 0 {
+			name, want := strings.TrimSpace(t[:i]), strings.TrimSpace(t[i+2:])
+			if name == "" {
+				return false, fmt.Errorf("missing name before %s", op)
+			}
+			have, _ := lookup(name)
+			return (have == strings.Trim(want, `"'`)) == (op == "=="), nil
+		}
+	}
+	if t == "" {
+		return false, fmt.Errorf("empty term")
+	}
+	v, ok := lookup(t)
+	return ok && v != "" && v != "0" && v != "false", nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ardnew/mkgo/exitcode"
+	"golang.org/x/mod/semver"
+)
+
+func init() {
+	registerCommand(&command{
+		name:  "self-update",
+		args:  "[-check]",
+		usage: "install the latest release of mkgo",
+		run:   runSelfUpdate,
+	})
+}
+
+const (
+	modulePath  = "github.com/ardnew/mkgo"
+	releaseURL  = "https://api.github.com/repos/ardnew/mkgo/releases/latest"
+	httpTimeout = 30 * time.Second
+)
+
+// runSelfUpdate checks for a newer release of mkgo and, unless only checking,
+// installs it with "go install" and verifies the installed version.
+func runSelfUpdate(arg []string) exitcode.Code {
+	var argCheck bool
+	fs := flag.NewFlagSet("self-update", flag.ContinueOnError)
+	fs.BoolVar(&argCheck, "check", false, "only report whether an update is available")
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+
+	latest, err := latestVersion()
+	if nil != err {
+		logger.Error("cannot determine latest version", "error", err)
+		return exitcode.Network
+	}
+	current := "v" + moduleVersion()
+	if semver.Compare(semverOf(latest), current) <= 0 {
+		logger.Info("already up to date", "version", current)
+		return exitcode.OK
+	}
+	if argCheck {
+		logger.Info("update available", "version", current+" -> "+latest)
+		return exitcode.OK
+	}
+
+	if out, err := execCmd("", "go", "install", modulePath+"@"+latest); nil != err {
+		logger.Error("command failed", "command", "go install", "error", err, "output", out)
+		return exitcode.Install
+	}
+	bin, err := installedBinary()
+	if nil != err {
+		logger.Error("cannot locate installed binary", "error", err)
+		return exitcode.Install
+	}
+	out, err := execCmd("", bin, "-version")
+	if nil != err {
+		logger.Error("command failed", "command", bin+" -version", "error", err, "output", out)
+		return exitcode.Install
+	}
+	if !strings.Contains(out, strings.TrimPrefix(latest, "v")) {
+		logger.Error("installed version does not match "+latest, "output", out)
+		return exitcode.Install
+	}
+	// the running mkgo is not replaced if it was installed elsewhere, e.g., by
+	// a package manager.
+	if exe, err := os.Executable(); nil == err && !sameFile(exe, bin) {
+		logger.Warn("updated binary is not the running mkgo (check PATH)", "installed", bin, "running", exe)
+	}
+	logger.Info("successfully updated", "version", current+" -> "+latest, "path", bin)
+	return exitcode.OK
+}
+
+// latestVersion returns the latest released version of mkgo, querying each Go
+// module proxy configured in GOPROXY until one succeeds, or GitHub releases if
+// none of them do.
+func latestVersion() (string, error) {
+	client := http.Client{Timeout: httpTimeout}
+	proxy, _ := execCmd("", "go", "env", "GOPROXY")
+	for _, p := range strings.FieldsFunc(strings.TrimSpace(proxy), func(r rune) bool {
+		return r == ',' || r == '|'
+	}) {
+		if p == "direct" || p == "off" {
+			continue
+		}
+		var info struct{ Version string }
+		if err := getJSON(&client, strings.TrimSuffix(p, "/")+"/"+modulePath+"/@latest", &info); nil == err {
+			return info.Version, nil
+		}
+	}
+	var info struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := getJSON(&client, releaseURL, &info); nil != err {
+		return "", err
+	}
+	return info.TagName, nil
+}
+
+// getJSON decodes the JSON response body of an HTTP GET request to the given url
+// into v.
+func getJSON(client *http.Client, url string, v interface{}) error {
+	rsp, err := client.Get(url)
+	if nil != err {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, rsp.Status)
+	}
+	return json.NewDecoder(rsp.Body).Decode(v)
+}
+
+// installedBinary returns the path of the mkgo executable installed by
+// "go install".
+func installedBinary() (string, error) {
+	out, err := execCmd("", "go", "env", "GOBIN", "GOPATH")
+	if nil != err {
+		return "", fmt.Errorf("go env: %s", strings.TrimSpace(out))
+	}
+	env := strings.Split(strings.TrimSpace(out), "\n")
+	bin := strings.TrimSpace(env[0])
+	if bin == "" && len(env) > 1 {
+		if gopath := filepath.SplitList(strings.TrimSpace(env[1])); len(gopath) > 0 {
+			bin = filepath.Join(gopath[0], "bin")
+		}
+	}
+	exe := filepath.Join(bin, "mkgo")
+	if _, err := os.Stat(exe); nil != err {
+		if _, errExe := os.Stat(exe + ".exe"); nil != errExe {
+			return "", err
+		}
+		exe += ".exe"
+	}
+	return exe, nil
+}
+
+// sameFile returns whether or not the given paths a and b name the same file,
+// following symbolic links.
+func sameFile(a, b string) bool {
+	ai, aerr := os.Stat(a)
+	bi, berr := os.Stat(b)
+	return nil == aerr && nil == berr && os.SameFile(ai, bi)
+}
+
+// semverOf returns the given semantic version v, with or without its "v"
+// prefix, with the prefix required by package semver.
+func semverOf(v string) string {
+	return "v" + strings.TrimPrefix(v, "v")
+}
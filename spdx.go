@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ardnew/mkgo/scaffold"
+)
+
+// spdxURL is the URL of the details, including the text, of the license with a
+// given SPDX identifier in the SPDX license list.
+const spdxURL = "https://spdx.org/licenses/%s.json"
+
+// errUnknownLicense is returned by spdxLicense for a license that is not in
+// the SPDX license list.
+var errUnknownLicense = errors.New("unknown SPDX license identifier")
+
+// spdxID matches an SPDX license identifier.
+var spdxID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+-]*$`)
+
+// spdxDetails are the details of a license in the SPDX license list used by
+// mkgo.
+type spdxDetails struct {
+	LicenseID               string `json:"licenseId"`
+	Name                    string `json:"name"`
+	LicenseText             string `json:"licenseText"`
+	StandardLicenseTemplate string `json:"standardLicenseTemplate"`
+}
+
+// spdxCachePath returns the path of the cache file of the details of the
+// license with the given SPDX identifier id, in the licenses directory of the
+// mkgo subdirectory of the user's cache directory.
+func spdxCachePath(id string) string {
+	dir, err := os.UserCacheDir()
+	if nil != err {
+		return ""
+	}
+	return filepath.Join(dir, "mkgo", "licenses", id+".json")
+}
+
+// spdxLicense returns the LICENSE Template of the license with the given SPDX
+// identifier id, whose text is read from the cache or, if not cached, fetched
+// from the SPDX license list and then cached. Its copyright notice, if any, is
+// that of the -d year and each copyright holder.
+func spdxLicense(id string) (Template, error) {
+	if !spdxID.MatchString(id) {
+		return nil, errUnknownLicense
+	}
+	var lic spdxDetails
+	path := spdxCachePath(id)
+	if b, err := os.ReadFile(path); nil != err || json.Unmarshal(b, &lic) != nil || lic.LicenseText == "" {
+		client := &http.Client{Timeout: httpTimeout}
+		rsp, err := client.Get(fmt.Sprintf(spdxURL, id))
+		if nil != err {
+			return nil, err
+		}
+		defer rsp.Body.Close()
+		switch {
+		case rsp.StatusCode == http.StatusNotFound:
+			return nil, errUnknownLicense
+		case rsp.StatusCode != http.StatusOK:
+			return nil, fmt.Errorf("%s: %s", rsp.Request.URL, rsp.Status)
+		}
+		if err := json.NewDecoder(rsp.Body).Decode(&lic); nil != err {
+			return nil, err
+		}
+		if lic.LicenseText == "" {
+			return nil, errUnknownLicense
+		}
+		if path != "" {
+			if b, err := json.MarshalIndent(&lic, "", "\t"); nil == err && os.MkdirAll(filepath.Dir(path), os.ModePerm) == nil {
+				if err := os.WriteFile(path, b, 0664); nil != err {
+					logger.Warn("cannot cache license", "license", id, "error", err)
+				}
+			}
+		}
+	}
+	text := lic.LicenseText
+	if lic.StandardLicenseTemplate != "" {
+		text = spdxText(lic.StandardLicenseTemplate)
+	}
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	return strings.Split(scaffold.Escape(text), "\n"), nil
+}
+
+var (
+	// spdxVar matches a replaceable text of an SPDX license template, capturing
+	// its attributes.
+	spdxVar = regexp.MustCompile(`<<var;((?:[^>"]|"(?:[^"\\]|\\.)*")*)>>`)
+	// spdxAttr matches an attribute of a replaceable text, capturing its name
+	// and value.
+	spdxAttr = regexp.MustCompile(`(\w+)="((?:[^"\\]|\\.)*)"`)
+	// spdxOptional matches the markers of optional text of an SPDX license
+	// template.
+	spdxOptional = regexp.MustCompile(`<<(?:beginOptional|endOptional)[^>]*>>`)
+)
+
+// spdxText returns the text of the license with the given SPDX license
+// template tmpl, keeping its optional text and the original of each
+// replaceable text, except its copyright notice, which is replaced by that of
+// the -d year and each copyright holder.
+func spdxText(tmpl string) string {
+	text := spdxVar.ReplaceAllStringFunc(tmpl, func(v string) string {
+		attr := map[string]string{}
+		for _, m := range spdxAttr.FindAllStringSubmatch(v, -1) {
+			attr[m[1]] = strings.ReplaceAll(m[2], `\"`, `"`)
+		}
+		if attr["name"] == "copyright" {
+			return "Copyright (c) __YEAR__ __HOLDER__"
+		}
+		return attr["original"]
+	})
+	return spdxOptional.ReplaceAllString(text, "")
+}
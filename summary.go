@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Summary describes the actions taken by applying a Plan.
+type Summary struct {
+	Import   string           `json:"import"`
+	Dir      string           `json:"dir"`
+	Files    []SummaryFile    `json:"files"`
+	Commands []SummaryCommand `json:"commands"`
+	Exit     int              `json:"exit"` // exit status of mkgo
+}
+
+// SummaryFile describes a file written, or skipped, by applying a Plan.
+type SummaryFile struct {
+	Path   string `json:"path"`
+	Action string `json:"action"` // either "created", "overwritten", or "skipped"
+	Size   int    `json:"size"`
+}
+
+// SummaryCommand describes a command run by applying a Plan.
+type SummaryCommand struct {
+	Args     []string      `json:"args"`
+	Duration time.Duration `json:"duration"` // in nanoseconds
+	Failed   bool          `json:"failed,omitempty"`
+}
+
+// addFile records the file at the given path with the given action and size in
+// the receiver Summary s, if not nil.
+func (s *Summary) addFile(path, action string, size int) {
+	if s != nil {
+		s.Files = append(s.Files, SummaryFile{Path: path, Action: action, Size: size})
+	}
+}
+
+// addCommand records the command with the given args, run since the given
+// start time, in the receiver Summary s, if not nil.
+func (s *Summary) addCommand(args []string, start time.Time, failed bool) {
+	if s != nil {
+		s.Commands = append(s.Commands, SummaryCommand{
+			Args: args, Duration: time.Since(start), Failed: failed,
+		})
+	}
+}
+
+// write writes the receiver Summary s to w, either as an aligned table of text
+// or, if asJSON is true, as a JSON object.
+func (s *Summary) write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tACTION\tSIZE")
+	for _, f := range s.Files {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", f.Path, f.Action, f.Size)
+	}
+	if err := tw.Flush(); nil != err {
+		return err
+	}
+	if len(s.Commands) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(tw, "COMMAND\tDURATION")
+		for _, c := range s.Commands {
+			d := c.Duration.Round(time.Millisecond).String()
+			if c.Failed {
+				d += " (failed)"
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", strings.Join(c.Args, " "), d)
+		}
+		if err := tw.Flush(); nil != err {
+			return err
+		}
+	}
+	if s.Exit == 0 {
+		_, err := fmt.Fprintf(w, "\ncreated %s in %s\n", s.Import, s.Dir)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ardnew/mkgo/exitcode"
+	"github.com/ardnew/mkgo/scaffold"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	registerCommand(&command{
+		name:  "template",
+		args:  "vars|lint <source> | diff [-stat] <module> <v1> <v2>",
+		usage: "inspect a template set",
+		run:   runTemplate,
+	})
+}
+
+// templateCommands contains every subcommand of the template command, keyed by
+// name.
+var templateCommands = map[string]func(arg []string) exitcode.Code{
+	"vars": runTemplateVars,
+	"lint": runTemplateLint,
+	"diff": runTemplateDiff,
+}
+
+// builtinVars describes each variable whose value is provided by mkgo for
+// every module.
+var builtinVars = map[string]string{
+	"IMPORT":      "import path",
+	"REPO":        "import path without major version",
+	"NAME":        "package name",
+	"PACKAGE":     "name of package clause: main, or with -lib",
+	"DATE":        "-d",
+	"YEAR":        "year of -d, or -copyright-years",
+	"VERSION":     "-s",
+	"USER":        "-u",
+	"EMAIL":       "-email",
+	"ORG":         "-org",
+	"HOLDER":      "copyright holders",
+	"AUTHOR":      "each author",
+	"DESCRIPTION": "-e description",
+	"KEYWORDS":    "-e keywords",
+	"DOC":         "each line of doc comment",
+	"NOTICE":      "each line of license notice",
+	"CHANGE":      "each changelog entry",
+	"LICENSE":     "-l",
+
+	"WithReadmeBadges":   "true unless -no-badges",
+	"WithChangelog":      "true unless -no-changelog",
+	"WithLicenseHeader":  "true if -spdx and -l",
+	"WithDebugEndpoints": "true if -debug-endpoints",
+	"WithMetrics":        "true if -metrics",
+}
+
+// runTemplate runs the subcommand of the template command named by the first
+// of the given arguments.
+func runTemplate(arg []string) exitcode.Code {
+	if len(arg) == 0 {
+		logger.Error("expected template command (use -h for help)")
+		return exitcode.Usage
+	}
+	run, ok := templateCommands[arg[0]]
+	if !ok {
+		logger.Error("unknown template command (use -h for help)", "command", arg[0])
+		return exitcode.Usage
+	}
+	return run(arg[1:])
+}
+
+// loadTemplateSet returns the template set found at the given source, either
+// the name of a template set in the search path or a directory, extending the
+// base declared by its manifest, if any.
+func loadTemplateSet(source string) (*scaffold.Set, exitcode.Code) {
+	return loadTemplateBase(source, "", map[string]bool{})
+}
+
+// loadTemplateBase returns the template set found at the given source, as with
+// loadTemplateSet, where a relative directory is relative to the directory from
+// of the template set extending it, if any. The given seen contains the
+// directories of the template sets extending it, which it must not extend.
+func loadTemplateBase(source, from string, seen map[string]bool) (*scaffold.Set, exitcode.Code) {
+	if from != "" && !filepath.IsAbs(source) &&
+		(strings.ContainsRune(source, '/') || strings.ContainsRune(source, filepath.Separator)) {
+		if _, isDir := fileExists(filepath.Join(from, source)); isDir {
+			source = filepath.Join(from, source)
+		}
+	}
+	dir, err := findTemplate(source)
+	if nil != err {
+		logger.Error("cannot find template set", "template", source, "error", err)
+		return nil, exitcode.Template
+	}
+	if abs, err := filepath.Abs(dir); nil == err {
+		dir = abs
+	}
+	if seen[dir] {
+		logger.Error("template set extends itself", "path", dir)
+		return nil, exitcode.Template
+	}
+	seen[dir] = true
+	set, err := scaffold.Load(os.DirFS(dir))
+	if nil != err {
+		logger.Error("cannot load template set", "path", dir, "error", err)
+		return nil, exitcode.Template
+	}
+	set.Remote = nil != downloaded[source]
+	if set.Manifest.Base == "" {
+		return set, exitcode.OK
+	}
+	base, code := loadTemplateBase(set.Manifest.Base, dir, seen)
+	if code != exitcode.OK {
+		return nil, code
+	}
+	ext, err := set.Extend(base)
+	if nil != err {
+		logger.Error("cannot extend template set", "path", dir, "base", set.Manifest.Base, "error", err)
+		return nil, exitcode.Template
+	}
+	return ext, exitcode.OK
+}
+
+// promptTemplateVars asks the user, if stdin is a terminal, for the value of
+// each variable declared required by the given template set that is not
+// defined in the given variables vars, until a valid value is given, and adds
+// it to vars.
+func promptTemplateVars(set *scaffold.Set, vars map[string]string) {
+	for _, name := range set.Missing(vars) {
+		v := set.Var(name)
+		prompt := name
+		if v.Description != "" {
+			prompt += " (" + v.Description + ")"
+		}
+		switch {
+		case len(v.Choices) > 0:
+			prompt += " [" + strings.Join(v.Choices, "|") + "]"
+		case v.Type != "" && v.Type != scaffold.TypeString:
+			prompt += " [" + v.Type + "]"
+		}
+		for {
+			val, ok := ask(prompt)
+			if !ok {
+				return
+			}
+			if _, err := v.Parse(val); nil != err {
+				fmt.Fprintf(os.Stderr, "mkgo: %s: %v\n", name, err)
+				continue
+			}
+			vars[name] = val
+			break
+		}
+	}
+}
+
+// runTemplateVars writes a table of every variable referenced by the template
+// set given as argument, its type, default value, whether or not it is
+// required, and its description, to stdout.
+func runTemplateVars(arg []string) exitcode.Code {
+	fs := flag.NewFlagSet("template vars", flag.ContinueOnError)
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if fs.NArg() != 1 {
+		logger.Error("expected one template set (use -h for help)")
+		return exitcode.Usage
+	}
+	set, code := loadTemplateSet(fs.Arg(0))
+	if code != exitcode.OK {
+		return code
+	}
+	return writeTemplateVars(os.Stdout, fs.Arg(0), set)
+}
+
+// writeTemplateVars writes a table of every variable referenced by the given
+// template set, read from source, its type, default value, whether or not it
+// is required, and its description, to w.
+func writeTemplateVars(out io.Writer, source string, set *scaffold.Set) exitcode.Code {
+	ref, err := set.Refs()
+	if nil != err {
+		logger.Error("cannot read template set", "path", source, "error", err)
+		return exitcode.Template
+	}
+	name := ref
+	seen := map[string]bool{}
+	for _, n := range ref {
+		seen[n] = true
+	}
+	cond := map[string]bool{}
+	for _, r := range set.Manifest.Files {
+		for _, n := range scaffold.Names(r.When) {
+			cond[n] = true
+		}
+	}
+	decl := map[string]scaffold.Var{}
+	for _, v := range set.Manifest.Vars {
+		decl[v.Name] = v
+		if !seen[v.Name] {
+			name = append(name, v.Name)
+		}
+	}
+	sort.Strings(name)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VARIABLE\tTYPE\tDEFAULT\tREQUIRED\tDESCRIPTION")
+	for _, n := range name {
+		typ, def, req, desc := scaffold.TypeString, "", "no", ""
+		switch v, ok := decl[n]; {
+		case ok:
+			def, desc = v.Default, v.Description
+			if v.Type != "" {
+				typ = v.Type
+			}
+			if len(v.Choices) > 0 {
+				typ = strings.Join(v.Choices, "|")
+			}
+			if v.Required {
+				req = "yes"
+			}
+		case builtinVars[n] != "":
+			def = "(" + builtinVars[n] + ")"
+		case flag.Lookup(n) != nil:
+			def = "(-" + n + ")"
+		case cond[n]:
+			typ, def = scaffold.TypeBool, "(false)" // undefined names in conditions are false
+		default:
+			req = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", n, typ, strings.TrimSpace(def), req, desc)
+	}
+	if err := w.Flush(); nil != err {
+		logger.Error("cannot write variables", "error", err)
+		return exitcode.Template
+	}
+	return exitcode.OK
+}
+
+// runTemplateLint writes every problem found in the template set given as
+// argument to stdout, one per line, and fails if any are found.
+func runTemplateLint(arg []string) exitcode.Code {
+	fs := flag.NewFlagSet("template lint", flag.ContinueOnError)
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if fs.NArg() != 1 {
+		logger.Error("expected one template set (use -h for help)")
+		return exitcode.Usage
+	}
+	set, code := loadTemplateSet(fs.Arg(0))
+	if code != exitcode.OK {
+		return code
+	}
+	prob, err := set.Lint(exampleVars())
+	if nil != err {
+		logger.Error("cannot read template set", "path", fs.Arg(0), "error", err)
+		return exitcode.Template
+	}
+	for _, p := range prob {
+		fmt.Println(p)
+	}
+	if len(prob) > 0 {
+		logger.Error("template set has problems", "path", fs.Arg(0), "count", len(prob))
+		return exitcode.Template
+	}
+	return exitcode.OK
+}
+
+// runTemplateDiff writes the differences between the template sets of the
+// module given as argument at the two given versions, in unified format, to
+// stdout, or only the path of each file added, deleted, or modified if
+// listing.
+func runTemplateDiff(arg []string) exitcode.Code {
+	var argStat bool
+	fs := flag.NewFlagSet("template diff", flag.ContinueOnError)
+	fs.BoolVar(&argStat, "stat", false, "list the files added (A), deleted (D), and modified (M) only")
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if fs.NArg() != 3 {
+		logger.Error("expected module and two versions (use -h for help)")
+		return exitcode.Usage
+	}
+	var file [2]map[string][]byte
+	for i, ver := range fs.Args()[1:] {
+		source := fs.Arg(0) + "@" + ver
+		if !isModuleQuery(source) {
+			logger.Error("not a module query", "template", source)
+			return exitcode.Usage
+		}
+		set, code := loadTemplateSet(source)
+		if code != exitcode.OK {
+			return code
+		}
+		if file[i], code = templateContent(source, set); code != exitcode.OK {
+			return code
+		}
+	}
+	path := []string{}
+	for p := range file[0] {
+		path = append(path, p)
+	}
+	for p := range file[1] {
+		if _, ok := file[0][p]; !ok {
+			path = append(path, p)
+		}
+	}
+	sort.Strings(path)
+	for _, p := range path {
+		a, inA := file[0][p]
+		b, inB := file[1][p]
+		nameA, nameB := "a/"+p, "b/"+p
+		switch {
+		case inA && inB && bytes.Equal(a, b):
+			continue
+		case argStat:
+			stat := "M"
+			if !inA {
+				stat = "A"
+			} else if !inB {
+				stat = "D"
+			}
+			fmt.Printf("%s\t%s\n", stat, p)
+			continue
+		case !inA:
+			nameA = "/dev/null"
+		case !inB:
+			nameB = "/dev/null"
+		}
+		if scaffold.IsBinary(a) || scaffold.IsBinary(b) {
+			fmt.Printf("Binary files %s and %s differ\n", nameA, nameB)
+			continue
+		}
+		writeDiff(os.Stdout, nameA, nameB, string(a), string(b))
+	}
+	return exitcode.OK
+}
+
+// templateContent returns the content of every file of the given template set,
+// read from source, keyed by path, including its manifest, as merged with its
+// bases, at ManifestName.
+func templateContent(source string, set *scaffold.Set) (map[string][]byte, exitcode.Code) {
+	file := map[string][]byte{}
+	err := fs.WalkDir(set.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if nil != err || d.IsDir() || p == scaffold.ManifestName {
+			return err
+		}
+		file[p], err = fs.ReadFile(set.FS, p)
+		return err
+	})
+	if nil != err {
+		logger.Error("cannot read template set", "path", source, "error", err)
+		return nil, exitcode.Template
+	}
+	if file[scaffold.ManifestName], err = yaml.Marshal(&set.Manifest); nil != err {
+		logger.Error("cannot read template set", "path", source, "error", err)
+		return nil, exitcode.Template
+	}
+	return file, exitcode.OK
+}
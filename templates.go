@@ -0,0 +1,498 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ardnew/mkgo/exitcode"
+	"github.com/ardnew/mkgo/scaffold"
+)
+
+func init() {
+	registerCommand(&command{
+		name:  "templates",
+		args:  "list | search <query> | describe [-preview] <name>",
+		usage: "discover the template sets found in the search path and the built-in types",
+		run:   runTemplates,
+	})
+}
+
+// templatesCommands contains every subcommand of the templates command, keyed
+// by name.
+var templatesCommands = map[string]func(arg []string) exitcode.Code{
+	"list":     runTemplatesList,
+	"search":   runTemplatesSearch,
+	"describe": runTemplatesDescribe,
+}
+
+// templatePath returns the directories searched, in order, for template sets
+// given by name: those listed in the configuration file followed by the
+// templates subdirectory of mkgo's configuration directory.
+func templatePath() []string {
+	dir := append([]string{}, config.TemplatePath...)
+	if cfg := configPath(); cfg != "" {
+		dir = append(dir, filepath.Join(filepath.Dir(cfg), "templates"))
+	}
+	return dir
+}
+
+// templateSet is a template set found in the search path, or a built-in type
+// of main package given with -type, which has no directory.
+type templateSet struct {
+	name    string
+	dir     string
+	builtin bool
+}
+
+// findTemplates returns every template set in the search path, sorted by name.
+// A template set in an earlier directory of the search path overrides those of
+// the same name in later directories.
+func findTemplates() []templateSet {
+	seen := map[string]bool{}
+	set := []templateSet{}
+	for _, dir := range templatePath() {
+		ent, err := os.ReadDir(dir)
+		if nil != err {
+			continue
+		}
+		for _, e := range ent {
+			if e.IsDir() && !seen[e.Name()] && !strings.HasPrefix(e.Name(), ".") {
+				seen[e.Name()] = true
+				set = append(set, templateSet{name: e.Name(), dir: filepath.Join(dir, e.Name())})
+			}
+		}
+	}
+	sort.Slice(set, func(i, j int) bool { return set[i].name < set[j].name })
+	return set
+}
+
+// builtinTemplates returns every built-in type of main package given with
+// -type, sorted by name.
+func builtinTemplates() []templateSet {
+	set := []templateSet{}
+	for _, name := range appTypeNames() {
+		set = append(set, templateSet{name: name, builtin: true})
+	}
+	return set
+}
+
+// templateNames returns the sorted names of every template set in the search
+// path.
+func templateNames() []string {
+	name := []string{}
+	for _, t := range findTemplates() {
+		name = append(name, t.name)
+	}
+	return name
+}
+
+// findTemplate returns the directory of the template set given by source:
+// either the name of a template set in the search path or, if source is a
+// path or no template set has that name, a directory. A module query that is
+// not a directory is downloaded through the Go module proxy.
+func findTemplate(source string) (string, error) {
+	if _, err := os.Stat(source); nil != err && isModuleQuery(source) {
+		info, err := downloadModule(source)
+		if nil != err {
+			return "", err
+		}
+		logger.Info("downloaded template module", "module", info.Path, "version", info.Version)
+		return info.Dir, nil
+	}
+	if !strings.ContainsRune(source, filepath.Separator) && !strings.ContainsRune(source, '/') &&
+		source != "." && source != ".." {
+		for _, t := range findTemplates() {
+			if t.name == source {
+				return t.dir, nil
+			}
+		}
+	}
+	info, err := os.Stat(source)
+	if nil != err {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("no template set or directory found (search path: %s)",
+				strings.Join(templatePath(), string(filepath.ListSeparator)))
+		}
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("not a directory: %s", source)
+	}
+	return source, nil
+}
+
+// runTemplates runs the subcommand of the templates command named by the first
+// of the given arguments.
+func runTemplates(arg []string) exitcode.Code {
+	if len(arg) == 0 {
+		logger.Error("expected templates command (use -h for help)")
+		return exitcode.Usage
+	}
+	run, ok := templatesCommands[arg[0]]
+	if !ok {
+		logger.Error("unknown templates command (use -h for help)", "command", arg[0])
+		return exitcode.Usage
+	}
+	return run(arg[1:])
+}
+
+// runTemplatesList writes a table of every template set found in the search
+// path, its location, and its description, followed by the built-in types of
+// main packages, to stdout.
+func runTemplatesList(arg []string) exitcode.Code {
+	fs := flag.NewFlagSet("templates list", flag.ContinueOnError)
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if fs.NArg() != 0 {
+		logger.Error("unexpected arguments (use -h for help)")
+		return exitcode.Usage
+	}
+	return writeTemplates(os.Stdout, append(findTemplates(), builtinTemplates()...), "")
+}
+
+// runTemplatesSearch writes a table of every template set found in the search
+// path, or built-in type of main package, whose name or description contains
+// the given query, ignoring case, to stdout.
+func runTemplatesSearch(arg []string) exitcode.Code {
+	fs := flag.NewFlagSet("templates search", flag.ContinueOnError)
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if fs.NArg() != 1 {
+		logger.Error("expected one query (use -h for help)")
+		return exitcode.Usage
+	}
+	return writeTemplates(os.Stdout, append(findTemplates(), builtinTemplates()...), fs.Arg(0))
+}
+
+// writeTemplates writes a table of the given template sets whose name or
+// description contains the given query, ignoring case, to out.
+func writeTemplates(out io.Writer, set []templateSet, query string) exitcode.Code {
+	query = strings.ToLower(query)
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH\tDESCRIPTION")
+	for _, t := range set {
+		loc, desc := t.dir, ""
+		if t.builtin {
+			loc, desc = "(built-in -type)", appTypeDescription[t.name]
+		} else if s, err := scaffold.Load(os.DirFS(t.dir)); nil == err {
+			desc = s.Manifest.Description
+		} else {
+			desc = "(invalid: " + err.Error() + ")"
+		}
+		if !strings.Contains(strings.ToLower(t.name+"\n"+desc), query) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", t.name, loc, desc)
+	}
+	if err := w.Flush(); nil != err {
+		logger.Error("cannot write template sets", "error", err)
+		return exitcode.Template
+	}
+	return exitcode.OK
+}
+
+// runTemplatesDescribe writes the manifest, variables, and files of the
+// template set given as argument to stdout, followed by each file rendered
+// with example values if previewing. A name that is neither a template set in
+// the search path nor a directory describes the built-in type of main package
+// of that name, if any.
+func runTemplatesDescribe(arg []string) exitcode.Code {
+	var argPreview bool
+	fs := flag.NewFlagSet("templates describe", flag.ContinueOnError)
+	fs.BoolVar(&argPreview, "preview", false, "render each file with example values")
+	if err := fs.Parse(arg); nil != err {
+		return exitcode.Usage
+	}
+	if fs.NArg() != 1 {
+		logger.Error("expected one template set (use -h for help)")
+		return exitcode.Usage
+	}
+	source := fs.Arg(0)
+	if app, ok := appTypes[source]; ok {
+		if _, err := findTemplate(source); nil != err {
+			return describeAppType(os.Stdout, source, app, argPreview)
+		}
+	}
+	set, code := loadTemplateSet(source)
+	if code != exitcode.OK {
+		return code
+	}
+	m := &set.Manifest
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, f := range [][2]string{
+		{"name", m.Name},
+		{"description", m.Description},
+		{"base", m.Base},
+		{"min-version", m.MinVersion},
+		{"requires", strings.Join(m.Requires, " ")},
+		{"pre", strings.Join(m.Hooks.Pre, "; ")},
+		{"post", strings.Join(m.Hooks.Post, "; ")},
+	} {
+		if f[1] != "" {
+			fmt.Fprintf(w, "%s:\t%s\n", f[0], f[1])
+		}
+	}
+	if err := w.Flush(); nil != err {
+		logger.Error("cannot write template set", "error", err)
+		return exitcode.Template
+	}
+	fmt.Println()
+	if code := writeTemplateVars(os.Stdout, source, set); code != exitcode.OK {
+		return code
+	}
+	fmt.Println()
+	if code := writeTemplateFiles(os.Stdout, source, set); code != exitcode.OK {
+		return code
+	}
+	if argPreview {
+		return writeTemplatePreview(os.Stdout, source, set)
+	}
+	return exitcode.OK
+}
+
+// appTypeFile is a file of a built-in type of main package, and the flags
+// with which it is created.
+type appTypeFile struct {
+	path string
+	when string
+	tmpl Template
+}
+
+// appTypeFiles returns the files of the given type of main package, each
+// created with the given flags when, if any, and those of its variants.
+func appTypeFiles(app appType, when string) []appTypeFile {
+	and := func(flag string) string {
+		if when == "" {
+			return flag
+		}
+		return when + " && " + flag
+	}
+	if app.broker != nil {
+		name := []string{}
+		for n := range app.broker {
+			name = append(name, n)
+		}
+		sort.Strings(name)
+		file := []appTypeFile{}
+		for _, n := range name {
+			file = append(file, appTypeFiles(app.broker[n], and("-broker "+n))...)
+		}
+		return file
+	}
+	file := []appTypeFile{}
+	if app.file == nil {
+		file = append(file, appTypeFile{"{{.NAME}}.go", when, template})
+	}
+	for _, f := range app.file {
+		file = append(file, appTypeFile{f.path, when, f.tmpl})
+	}
+	if app.server {
+		file = append(file, appTypeFile{"debug.go", and("-debug-endpoints"), debugTemplate})
+	}
+	if nil != app.metrics {
+		file = append(file, appTypeFile{"metrics.go", and("-metrics"), app.metrics})
+	}
+	if len(app.target) > 0 {
+		file = append(file, appTypeFile{"Makefile", when, makefile(app.target...)})
+	}
+	if nil != app.gateway {
+		// only the files that differ from those without -gateway are listed.
+		base := map[string]string{}
+		for _, f := range file {
+			base[f.path+"\n"+f.when] = f.tmpl.String()
+		}
+		for _, f := range appTypeFiles(*app.gateway, when) {
+			if text, ok := base[f.path+"\n"+f.when]; !ok || text != f.tmpl.String() {
+				if f.when == "" {
+					f.when = "-gateway"
+				} else {
+					f.when += " && -gateway"
+				}
+				file = append(file, f)
+			}
+		}
+	}
+	return file
+}
+
+// describeAppType writes the description, required modules, and files of the
+// built-in type of main package app with the given name to out, followed by
+// each file rendered with example values if previewing.
+func describeAppType(out io.Writer, name string, app appType, preview bool) exitcode.Code {
+	vars := exampleVars()
+	if app.lib {
+		vars["PACKAGE"] = libPackage(vars["NAME"])
+	}
+	require := append([]string{}, app.require...)
+	for _, v := range app.broker {
+		require = append(require, v.require...)
+	}
+	if nil != app.gateway {
+		for _, r := range app.gateway.require {
+			if !slices.Contains(require, r) {
+				require = append(require, r)
+			}
+		}
+	}
+	given := "-type " + name
+	if app.lib {
+		given = "-lib " + given
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, f := range [][2]string{
+		{"name", name},
+		{"description", appTypeDescription[name]},
+		{"built-in", given},
+		{"requires", strings.Join(require, " ")},
+	} {
+		if f[1] != "" {
+			fmt.Fprintf(w, "%s:\t%s\n", f[0], f[1])
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "FILE\tWHEN")
+	file := appTypeFiles(app, "")
+	for i := range file {
+		p, code := renderPath(file[i].path, vars)
+		if code != exitcode.OK {
+			return code
+		}
+		file[i].path = p
+		fmt.Fprintf(w, "%s\t%s\n", p, file[i].when)
+	}
+	if err := w.Flush(); nil != err {
+		logger.Error("cannot write template set", "error", err)
+		return exitcode.Template
+	}
+	if !preview {
+		return exitcode.OK
+	}
+	for _, f := range file {
+		body := append(Template{}, f.tmpl...)
+		if err := body.insert(vars, nil); nil != err {
+			logger.Error("cannot render template", "path", f.path, "error", err)
+			return exitcode.Template
+		}
+		fmt.Fprintf(out, "\n==> %s", f.path)
+		if f.when != "" {
+			fmt.Fprintf(out, " (%s)", f.when)
+		}
+		fmt.Fprintf(out, " <==\n%s", body.file())
+	}
+	return exitcode.OK
+}
+
+// writeTemplateFiles writes a table of every file of the given template set,
+// read from source, and the conditions under which it is rendered, to out.
+func writeTemplateFiles(out io.Writer, source string, set *scaffold.Set) exitcode.Code {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tWHEN")
+	err := fs.WalkDir(set.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if nil != err || p == scaffold.ManifestName || p == scaffold.ScriptName {
+			return err
+		}
+		if d.IsDir() {
+			if p == scaffold.PartialDir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		when := set.When(p)
+		for i := range when {
+			if len(when) > 1 && strings.ContainsAny(when[i], "|&") {
+				when[i] = "(" + when[i] + ")"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\n", p, strings.Join(when, " && "))
+		return nil
+	})
+	if nil != err {
+		logger.Error("cannot read template set", "path", source, "error", err)
+		return exitcode.Template
+	}
+	if err := w.Flush(); nil != err {
+		logger.Error("cannot write template set", "error", err)
+		return exitcode.Template
+	}
+	return exitcode.OK
+}
+
+// exampleVars returns example values of every variable provided by mkgo (see
+// builtinVars).
+func exampleVars() map[string]string {
+	return map[string]string{
+		"IMPORT":      "example.com/user/example",
+		"REPO":        "example.com/user/example",
+		"NAME":        "example",
+		"PACKAGE":     "main",
+		"DATE":        time.Now().Format(dateFormat),
+		"YEAR":        time.Now().Format("2006"),
+		"VERSION":     semVersion,
+		"USER":        "user",
+		"EMAIL":       "user@example.com",
+		"ORG":         "Example Org",
+		"HOLDER":      "user",
+		"AUTHOR":      "user",
+		"DESCRIPTION": "An example module.",
+		"KEYWORDS":    "example",
+		"DOC":         "// Command example is an example.",
+		"NOTICE":      "//",
+		"CHANGE":      `"initial implementation"`,
+		"LICENSE":     "MIT",
+
+		"WithReadmeBadges":   "true",
+		"WithChangelog":      "true",
+		"WithLicenseHeader":  "",
+		"WithDebugEndpoints": "",
+		"WithMetrics":        "",
+	}
+}
+
+// writeTemplatePreview writes every file of the given template set, read from
+// source, rendered with example values of the variables provided by mkgo and
+// of those it requires, and the defaults declared by its manifest, to out.
+func writeTemplatePreview(out io.Writer, source string, set *scaffold.Set) exitcode.Code {
+	vars := exampleVars()
+	// required variables are given example values of their type.
+	for _, name := range set.Missing(vars) {
+		switch v := set.Var(name); {
+		case len(v.Choices) > 0:
+			vars[name] = v.Choices[0]
+		case v.Type == scaffold.TypeBool:
+			vars[name] = "true"
+		case v.Type == scaffold.TypeInt:
+			vars[name] = "1"
+		default:
+			vars[name] = "example"
+		}
+	}
+	file, err := set.Render(vars)
+	if nil != err {
+		logger.Error("cannot render template set", "path", source, "error", err)
+		return exitcode.Template
+	}
+	for _, f := range file {
+		fmt.Fprintf(out, "\n==> %s <==\n", f.Path)
+		if f.Binary {
+			fmt.Fprintf(out, "(binary, %d bytes)\n", len(f.Content))
+			continue
+		}
+		fmt.Fprintf(out, "%s", f.Content)
+		if len(f.Content) > 0 && f.Content[len(f.Content)-1] != '\n' {
+			fmt.Fprintln(out)
+		}
+	}
+	return exitcode.OK
+}
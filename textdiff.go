@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines surrounding each change written
+// by writeDiff.
+const diffContext = 3
+
+// diffMaxCells is the maximum product of the number of lines of two texts for
+// which writeDiff finds their longest common subsequence; larger texts are
+// instead written as if every line was replaced.
+const diffMaxCells = 1 << 22
+
+// diffLine is a line of a difference between two texts, with its kind: ' ' if
+// unchanged, '-' if removed, or '+' if added.
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// splitLines returns the lines of the given text, each without its newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the lines of the shortest difference, found from their
+// longest common subsequence, between the given lines a and b.
+func diffLines(a, b []string) []diffLine {
+	if len(a)*len(b) > diffMaxCells {
+		out := make([]diffLine, 0, len(a)+len(b))
+		for _, s := range a {
+			out = append(out, diffLine{'-', s})
+		}
+		for _, s := range b {
+			out = append(out, diffLine{'+', s})
+		}
+		return out
+	}
+	// lcs[i][j] is the length of the longest common subsequence of a[i:], b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	out := []diffLine{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, diffLine{' ', a[i]})
+			i, j = i+1, j+1
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, diffLine{'-', a[i]})
+			i++
+		default:
+			out = append(out, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return out
+}
+
+// writeDiff writes the difference between the given texts a and b, named
+// nameA and nameB, in unified format to out. Nothing is written if they are
+// equal.
+func writeDiff(out io.Writer, nameA, nameB, a, b string) {
+	line := diffLines(splitLines(a), splitLines(b))
+	changed := false
+	for _, l := range line {
+		changed = changed || l.kind != ' '
+	}
+	if !changed {
+		return
+	}
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", nameA, nameB)
+	// pos[k] is the line number, in a and b, preceding line[k].
+	pos := make([][2]int, len(line)+1)
+	for k, l := range line {
+		pos[k+1] = pos[k]
+		if l.kind != '+' {
+			pos[k+1][0]++
+		}
+		if l.kind != '-' {
+			pos[k+1][1]++
+		}
+	}
+	for k := 0; k < len(line); {
+		if line[k].kind == ' ' {
+			k++
+			continue
+		}
+		// extend the hunk until more than twice the context is unchanged.
+		start, end := max(0, k-diffContext), k
+		for same := 0; end < len(line) && same <= 2*diffContext; end++ {
+			if line[end].kind == ' ' {
+				same++
+			} else {
+				same = 0
+			}
+		}
+		for end > k && line[end-1].kind == ' ' {
+			end--
+		}
+		end = min(len(line), end+diffContext)
+		fmt.Fprintf(out, "@@ -%s +%s @@\n",
+			hunkRange(pos[start][0], pos[end][0]), hunkRange(pos[start][1], pos[end][1]))
+		for _, l := range line[start:end] {
+			fmt.Fprintf(out, "%c%s\n", l.kind, l.text)
+		}
+		k = end
+	}
+}
+
+// hunkRange returns the range of lines after line from through line to of a
+// hunk in unified format.
+func hunkRange(from, to int) string {
+	switch n := to - from; n {
+	case 0:
+		return fmt.Sprintf("%d,0", from)
+	case 1:
+		return fmt.Sprintf("%d", from+1)
+	default:
+		return fmt.Sprintf("%d,%d", from+1, n)
+	}
+}
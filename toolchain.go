@@ -0,0 +1,30 @@
+package main
+
+import "strings"
+
+// goVersion returns the version of the Go toolchain found in PATH (e.g.,
+// "1.22.3"), which is the version written to the go directive of go.mod by
+// "go mod init", or the empty string if it cannot be determined.
+func goVersion() string {
+	out, err := execCmd("", "go", "env", "GOVERSION")
+	if nil != err {
+		return ""
+	}
+	v := strings.TrimSpace(out)
+	if !strings.HasPrefix(v, "go") {
+		return "" // development toolchains have no release version
+	}
+	return strings.TrimPrefix(v, "go")
+}
+
+// goVersionFile returns the .go-version file, read by goenv and similar version
+// managers, that selects the given Go version ver.
+func goVersionFile(ver string) Template {
+	return Template{ver, ``}
+}
+
+// toolVersionsFile returns the asdf .tool-versions file that selects the given
+// Go version ver.
+func toolVersionsFile(ver string) Template {
+	return Template{`golang ` + ver, ``}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ardnew/mkgo/exitcode"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/mod/module"
+)
+
+// verifyTemplate verifies each template module downloaded, unless the receiver
+// options opt has insecure set: those not verified by the Go checksum database
+// must be pinned with -template-sum, and the one given with -t must match the
+// checksum given with -template-sum and the minisign signature given with
+// -template-sig, if any. Any failure is logged, and the exit status of mkgo is
+// returned.
+func (opt *options) verifyTemplate() exitcode.Code {
+	if opt.insecure {
+		for _, info := range downloaded {
+			logger.Warn("template module not verified (-insecure)", "module", info.Path, "version", info.Version)
+		}
+		return exitcode.OK
+	}
+	top := downloaded[opt.templates]
+	if (opt.templateSum != "" || opt.templateSig != "") && top == nil {
+		logger.Error("template set is not a module query (use -insecure to skip verification)",
+			"template", opt.templates)
+		return exitcode.Verify
+	}
+	if len(downloaded) == 0 {
+		return exitcode.OK
+	}
+	env, err := goEnv("GOSUMDB", "GONOSUMDB", "GOPRIVATE", "GOFLAGS")
+	if nil != err {
+		logger.Error("cannot determine go environment", "error", err)
+		return exitcode.Verify
+	}
+	for _, info := range downloaded {
+		pinned := info == top && opt.templateSum != ""
+		if why := sumdbBypass(env, info.Path); why != "" && !pinned {
+			logger.Error("template module not verified by checksum database (use -template-sum or -insecure)",
+				"module", info.Path, "reason", why)
+			return exitcode.Verify
+		}
+	}
+	if top == nil {
+		return exitcode.OK
+	}
+	if opt.templateSum != "" && opt.templateSum != top.Sum {
+		logger.Error("template module checksum mismatch (use -insecure to skip verification)",
+			"module", top.Path, "version", top.Version, "expected", opt.templateSum, "found", top.Sum)
+		return exitcode.Verify
+	}
+	key := opt.templateKey
+	if key == "" {
+		key = config.TemplateKey
+	}
+	switch {
+	case opt.templateSig == "":
+		return exitcode.OK
+	case key == "":
+		logger.Error("no public key to verify template signature (use -template-key)")
+		return exitcode.Verify
+	}
+	zip, err := os.ReadFile(top.Zip)
+	if nil != err {
+		logger.Error("cannot read template module", "module", top.Path, "error", err)
+		return exitcode.Verify
+	}
+	sig, err := readSource(opt.templateSig)
+	if nil != err {
+		logger.Error("cannot read template signature", "path", opt.templateSig, "error", err)
+		return exitcode.Verify
+	}
+	trusted, err := verifyMinisign(zip, sig, key)
+	if nil != err {
+		logger.Error("cannot verify template signature", "module", top.Path, "error", err)
+		return exitcode.Verify
+	}
+	logger.Info("verified template signature", "module", top.Path, "comment", trusted)
+	return exitcode.OK
+}
+
+// goEnv returns the values of the given go environment variables, as printed
+// by "go env".
+func goEnv(name ...string) (map[string]string, error) {
+	out, err := exec.Command("go", append([]string{"env", "-json"}, name...)...).Output()
+	if nil != err {
+		return nil, err
+	}
+	env := map[string]string{}
+	return env, json.Unmarshal(out, &env)
+}
+
+// sumdbBypass returns the reason why the module with the given path mod is not
+// verified by the Go checksum database in the given go environment env, or
+// the empty string if it is.
+func sumdbBypass(env map[string]string, mod string) string {
+	nosum := env["GONOSUMDB"]
+	if nosum == "" {
+		nosum = env["GOPRIVATE"]
+	}
+	switch {
+	case env["GOSUMDB"] == "off":
+		return "GOSUMDB=off"
+	case module.MatchPrefixPatterns(nosum, mod):
+		return "matched by GONOSUMDB or GOPRIVATE"
+	case strings.Contains(env["GOFLAGS"], "-insecure"):
+		return "GOFLAGS=-insecure"
+	}
+	return ""
+}
+
+// readSource returns the content of the file at the given path, or of the
+// response to an HTTP GET request if path is an http or https URL.
+func readSource(path string) ([]byte, error) {
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		return os.ReadFile(path)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	rsp, err := client.Get(path)
+	if nil != err {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", path, rsp.Status)
+	}
+	return io.ReadAll(io.LimitReader(rsp.Body, 1<<20))
+}
+
+// verifyMinisign verifies the given minisign (https://jedisct1.github.io/minisign)
+// signature sig of the given message msg with the given public key, either
+// the base64-encoded key itself or the path of a file containing it. The
+// trusted comment of the signature is returned.
+func verifyMinisign(msg, sig []byte, key string) (trusted string, err error) {
+	if b, err := os.ReadFile(key); nil == err {
+		key = lastLine(b)
+	}
+	pk, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if nil != err || len(pk) != 42 || string(pk[:2]) != "Ed" {
+		return "", errors.New("invalid public key")
+	}
+	line := []string{}
+	for scan := bufio.NewScanner(bytes.NewReader(sig)); scan.Scan(); {
+		if s := strings.TrimSpace(scan.Text()); s != "" {
+			line = append(line, s)
+		}
+	}
+	if len(line) != 4 || !strings.HasPrefix(line[2], "trusted comment: ") {
+		return "", errors.New("invalid signature file")
+	}
+	s, err := base64.StdEncoding.DecodeString(line[1])
+	if nil != err || len(s) != 74 {
+		return "", errors.New("invalid signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(line[3])
+	if nil != err || len(global) != ed25519.SignatureSize {
+		return "", errors.New("invalid global signature")
+	}
+	if !bytes.Equal(s[2:10], pk[2:10]) {
+		return "", fmt.Errorf("signed by another key: %X", s[2:10])
+	}
+	switch string(s[:2]) {
+	case "Ed":
+	case "ED": // pre-hashed
+		sum := blake2b.Sum512(msg)
+		msg = sum[:]
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm: %q", s[:2])
+	}
+	pub := ed25519.PublicKey(pk[10:])
+	if !ed25519.Verify(pub, msg, s[10:]) {
+		return "", errors.New("signature does not match")
+	}
+	trusted = strings.TrimPrefix(line[2], "trusted comment: ")
+	if !ed25519.Verify(pub, append(append([]byte{}, s[10:]...), trusted...), global) {
+		return "", errors.New("trusted comment does not match")
+	}
+	return trusted, nil
+}
+
+// lastLine returns the last non-empty line of the given text b.
+func lastLine(b []byte) string {
+	line := strings.Split(strings.TrimSpace(string(b)), "\n")
+	return strings.TrimSpace(line[len(line)-1])
+}
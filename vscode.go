@@ -0,0 +1,46 @@
+package main
+
+// vscodeSettings returns the VS Code workspace settings that configure gopls to
+// format Go source files consistently with the given source formatter.
+func vscodeSettings(format string) Template {
+	gofumpt, imports := "false", "explicit"
+	switch format {
+	case "gofmt":
+		imports = "never"
+	case "gofumpt":
+		gofumpt = "true"
+	}
+	return Template{
+		`{`,
+		`	"go.useLanguageServer": true,`,
+		`	"gopls": {`,
+		`		"formatting.gofumpt": ` + gofumpt,
+		`	},`,
+		`	"[go]": {`,
+		`		"editor.defaultFormatter": "golang.go",`,
+		`		"editor.formatOnSave": true,`,
+		`		"editor.codeActionsOnSave": {`,
+		`			"source.organizeImports": "` + imports + `"`,
+		`		}`,
+		`	}`,
+		`}`,
+	}
+}
+
+// vscodeLaunch is the VS Code launch configuration that debugs the generated
+// executable.
+var vscodeLaunch = Template{
+	`{`,
+	`	"version": "0.2.0",`,
+	`	"configurations": [`,
+	`		{`,
+	`			"name": "Launch __NAME__",`,
+	`			"type": "go",`,
+	`			"request": "launch",`,
+	`			"mode": "auto",`,
+	`			"program": "${workspaceFolder}",`,
+	`			"args": ["-v"]`,
+	`		}`,
+	`	]`,
+	`}`,
+}